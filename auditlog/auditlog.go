@@ -0,0 +1,121 @@
+package auditlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/logging"
+	"os"
+	"strings"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Entry struct {
+	Time      time.Time `json:"time"`
+	RunId     string    `json:"runId"`
+	Repo      string    `json:"repo"`
+	Issue     int       `json:"issue,omitempty"`
+	Operation string    `json:"operation"`
+	Before    string    `json:"before,omitempty"`
+	After     string    `json:"after,omitempty"`
+	Rule      string    `json:"rule"`
+}
+
+type Filter struct {
+	Repo  string
+	Issue int
+	Rule  string
+}
+
+type auditlog struct {
+	path  string
+	runId string
+	clock Clock
+}
+
+func New(path string, runId string, clock Clock) *auditlog {
+	return &auditlog{path, runId, clock}
+}
+
+func NewRunId(clock Clock) string {
+	randomBytes := make([]byte, 4)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		logging.Fatal(logging.Fields{}, "cannot generate a run ID", err)
+	}
+	return clock.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(randomBytes)
+}
+
+func (auditlog *auditlog) Record(auditEntry githubstructures.AuditEntry) {
+	entry := Entry{
+		Time:      auditlog.clock.Now(),
+		RunId:     auditlog.runId,
+		Repo:      auditEntry.Repo,
+		Issue:     auditEntry.Issue,
+		Operation: auditEntry.Operation,
+		Before:    auditEntry.Before,
+		After:     auditEntry.After,
+		Rule:      auditEntry.Rule,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logging.Fatal(logging.Fields{Repo: entry.Repo, Issue: entry.Issue, Rule: entry.Rule, Operation: entry.Operation}, "cannot encode the audit entry", err)
+	}
+	file, err := os.OpenFile(auditlog.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Fatal(logging.Fields{}, "cannot open the audit log", auditlog.path, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		logging.Fatal(logging.Fields{}, "cannot write the audit log", auditlog.path, err)
+	}
+}
+
+func matches(entry Entry, filter Filter) bool {
+	if filter.Repo != "" && entry.Repo != filter.Repo {
+		return false
+	}
+	if filter.Issue != 0 && entry.Issue != filter.Issue {
+		return false
+	}
+	if filter.Rule != "" && !strings.EqualFold(entry.Rule, filter.Rule) && !strings.HasPrefix(strings.ToLower(entry.Rule), strings.ToLower(filter.Rule)+":") {
+		return false
+	}
+	return true
+}
+
+func Query(path string, filter Filter) ([]Entry, error) {
+	entries := []Entry{}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		entry := Entry{}
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return nil, err
+		}
+		if matches(entry, filter) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
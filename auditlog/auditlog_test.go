@@ -0,0 +1,102 @@
+package auditlog
+
+import (
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type Mockclock struct{}
+
+func (clock Mockclock) Now() time.Time {
+	return time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+}
+
+func TestAuditlog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "auditlog")
+}
+
+var _ = Describe("auditlog", func() {
+	var dir string
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "auditlog")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "audit.jsonl")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("generates run IDs", func() {
+		runId := NewRunId(Mockclock{})
+
+		Expect(regexp.MustCompile(`^20200713T100000Z-[0-9a-f]{8}$`).MatchString(runId)).To(BeTrue())
+		Expect(NewRunId(Mockclock{})).NotTo(Equal(runId))
+	})
+
+	It("appends entries across runs", func() {
+		New(path, "run-1", Mockclock{}).Record(githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "stale", Rule: "stale"})
+		New(path, "run-2", Mockclock{}).Record(githubstructures.AuditEntry{Repo: "repo-2", Operation: "DeleteLabel", Before: "bug #ff0000", Rule: "migration: merge"})
+
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(
+			`{"time":"2020-07-13T10:00:00Z","runId":"run-1","repo":"repo-1","issue":7,"operation":"AddLabel","after":"stale","rule":"stale"}` + "\n" +
+				`{"time":"2020-07-13T10:00:00Z","runId":"run-2","repo":"repo-2","operation":"DeleteLabel","before":"bug #ff0000","rule":"migration: merge"}` + "\n",
+		))
+	})
+
+	It("queries entries by repo, issue and rule", func() {
+		auditlog := New(path, "run-1", Mockclock{})
+		auditlog.Record(githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "stale", Rule: "stale"})
+		auditlog.Record(githubstructures.AuditEntry{Repo: "repo-1", Issue: 8, Operation: "RemoveLabel", Before: "bug", Rule: "migration: merge"})
+		auditlog.Record(githubstructures.AuditEntry{Repo: "repo-2", Issue: 7, Operation: "CloseIssue", Before: "open", After: "closed", Rule: "stale"})
+
+		entries, err := Query(path, Filter{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+
+		entries, err = Query(path, Filter{Repo: "repo-1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		entries, err = Query(path, Filter{Repo: "repo-1", Issue: 7})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]Entry{
+			Entry{Time: Mockclock{}.Now(), RunId: "run-1", Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "stale", Rule: "stale"},
+		}))
+
+		entries, err = Query(path, Filter{Rule: "migration"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Operation).To(Equal("RemoveLabel"))
+
+		entries, err = Query(path, Filter{Rule: "Stale"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+	})
+
+	It("queries a missing log", func() {
+		entries, err := Query(path, Filter{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]Entry{}))
+	})
+
+	It("rejects an invalid log", func() {
+		Expect(ioutil.WriteFile(path, []byte("{\n"), 0644)).To(Succeed())
+
+		_, err := Query(path, Filter{})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	exitSuccess        = 0
+	exitFailure        = 1
+	exitUsage          = 2
+	exitPartialFailure = 3
+)
+
+type globalOptions struct {
+	organization string
+	tokenSource  string
+	configPath   string
+	isDryRun     bool
+	logFormat    string
+	logLevel     string
+}
+
+type command struct {
+	name              string
+	arguments         string
+	description       string
+	needsOrganization bool
+	run               func(options globalOptions, flagSet *flag.FlagSet, args []string) int
+	subcommands       []command
+}
+
+type stringsFlag []string
+
+func (values *stringsFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+func envOr(name string, defaultValue string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func printCommands(path string, commands []command) {
+	fmt.Fprintln(os.Stderr, "commands:")
+	for i := 0; i < len(commands); i++ {
+		fmt.Fprintln(os.Stderr, " ", strings.TrimSpace(commands[i].name+" "+commands[i].arguments))
+		fmt.Fprintln(os.Stderr, "    ", commands[i].description)
+	}
+	fmt.Fprintln(os.Stderr, "Run \""+strings.TrimSpace("issue-overseer "+path)+" <command> --help\" for the flags of a command.")
+}
+
+func newGlobalFlagSet(options *globalOptions, commands []command) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("issue-overseer", flag.ContinueOnError)
+	flagSet.StringVar(&options.organization, "org", os.Getenv("GITHUB_ORGANIZATION"), "the GitHub organization, $GITHUB_ORGANIZATION by default")
+	flagSet.StringVar(&options.tokenSource, "token-source", "env:GITHUB_TOKEN", "where to read the GitHub token from: env:<variable>, file:<path> or command:<shell command>")
+	flagSet.StringVar(&options.configPath, "config", os.Getenv("CONFIG_PATH"), "the YAML config file, $CONFIG_PATH by default")
+	flagSet.BoolVar(&options.isDryRun, "dry-run", false, "only log the changes instead of making them on GitHub")
+	flagSet.StringVar(&options.logFormat, "log-format", "text", "the log format: text, logfmt or json")
+	flagSet.StringVar(&options.logLevel, "log-level", "info", "the lowest logged level: debug, info, warn or error; debug also logs the whole issues")
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: issue-overseer [global flags] <command> [flags]")
+		fmt.Fprintln(os.Stderr, "global flags:")
+		flagSet.PrintDefaults()
+		printCommands("", commands)
+	}
+	return flagSet
+}
+
+func newFlagSet(path string, arguments string, description string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(path, flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: issue-overseer [global flags]", strings.TrimSpace(path+" "+arguments))
+		fmt.Fprintln(os.Stderr, description)
+		fmt.Fprintln(os.Stderr, "flags:")
+		flagSet.PrintDefaults()
+	}
+	return flagSet
+}
+
+func flagErrorExitCode(err error) int {
+	if err == flag.ErrHelp {
+		return exitSuccess
+	}
+	return exitUsage
+}
+
+func parseFlags(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	positionalArgs := []string{}
+	for {
+		err := flagSet.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = flagSet.Args()
+		if len(args) == 0 {
+			return positionalArgs, nil
+		}
+		positionalArgs = append(positionalArgs, args[0])
+		args = args[1:]
+	}
+}
+
+func usageError(flagSet *flag.FlagSet, message ...interface{}) int {
+	fmt.Fprintln(os.Stderr, message...)
+	flagSet.Usage()
+	return exitUsage
+}
+
+func parseIssueNumbers(values []string) ([]int, error) {
+	issueNumbers := []int{}
+	for i := 0; i < len(values); i++ {
+		number, err := strconv.Atoi(values[i])
+		if err != nil || number <= 0 {
+			return nil, errors.New("invalid issue number \"" + values[i] + "\"")
+		}
+		issueNumbers = append(issueNumbers, number)
+	}
+	return issueNumbers, nil
+}
+
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func hasHelpFlag(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		if args[i] != "help" && isHelp(args[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func runCommand(options globalOptions, path string, commands []command, args []string) int {
+	if len(args) == 0 || isHelp(args[0]) {
+		fmt.Fprintln(os.Stderr, "usage: issue-overseer [global flags]", path, "<command> [flags]")
+		printCommands(path, commands)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitSuccess
+	}
+	for i := 0; i < len(commands); i++ {
+		command := commands[i]
+		if command.name != args[0] {
+			continue
+		}
+		commandPath := strings.TrimSpace(path + " " + command.name)
+		if len(command.subcommands) > 0 {
+			return runCommand(options, commandPath, command.subcommands, args[1:])
+		}
+		flagSet := newFlagSet(commandPath, command.arguments, command.description)
+		if command.needsOrganization && options.organization == "" && !hasHelpFlag(args[1:]) {
+			return usageError(flagSet, "missing --org or $GITHUB_ORGANIZATION")
+		}
+		return command.run(options, flagSet, args[1:])
+	}
+	fmt.Fprintln(os.Stderr, "unknown command", strconv.Quote(args[0]))
+	printCommands(path, commands)
+	return exitUsage
+}
+
+func exitCodeOf(count int, failedCount int) int {
+	if failedCount == 0 {
+		return exitSuccess
+	}
+	if failedCount == count {
+		return exitFailure
+	}
+	return exitPartialFailure
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestCli(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "cli")
+}
+
+type Mocklabelsclient struct{}
+
+var mockFindIssuesByLabel func(repoName string, labelName string) []githubstructures.Issue
+
+func (githubClient Mocklabelsclient) FindRepos() []string {
+	return []string{}
+}
+
+func (githubClient Mocklabelsclient) FindLabels(repoName string) []githubstructures.Label {
+	return []githubstructures.Label{}
+}
+
+func (githubClient Mocklabelsclient) FindIssuesByLabel(repoName string, labelName string) []githubstructures.Issue {
+	return mockFindIssuesByLabel(repoName, labelName)
+}
+
+func (githubClient Mocklabelsclient) RequestsCount() int {
+	return 0
+}
+
+var _ = Describe("cli", func() {
+	var runArgs [][]string
+	commands := []command{
+		command{
+			name:              "daemon",
+			description:       "Syncs again and again.",
+			needsOrganization: true,
+			run: func(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+				runArgs = append(runArgs, args)
+				flagSet.Duration("interval", 0, "the pause between two syncs")
+				_, err := parseFlags(flagSet, args)
+				if err != nil {
+					return flagErrorExitCode(err)
+				}
+				return exitSuccess
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		runArgs = [][]string{}
+	})
+
+	It("prints the help of a command anywhere in its args without an organization", func() {
+		Expect(runCommand(globalOptions{}, "", commands, []string{"daemon", "--interval", "1s", "--help"})).To(Equal(exitSuccess))
+		Expect(runCommand(globalOptions{}, "", commands, []string{"daemon", "--interval", "1s", "-h"})).To(Equal(exitSuccess))
+		Expect(runCommand(globalOptions{organization: "org"}, "", commands, []string{"daemon", "--help"})).To(Equal(exitSuccess))
+		Expect(runArgs).To(Equal([][]string{
+			[]string{"--interval", "1s", "--help"},
+			[]string{"--interval", "1s", "-h"},
+			[]string{"--help"},
+		}))
+	})
+
+	It("requires an organization without a help flag", func() {
+		Expect(runCommand(globalOptions{}, "", commands, []string{"daemon", "--interval", "1s", "help"})).To(Equal(exitUsage))
+		Expect(runCommand(globalOptions{organization: "org"}, "", commands, []string{"daemon", "--interval", "1s"})).To(Equal(exitSuccess))
+		Expect(runArgs).To(Equal([][]string{
+			[]string{"--interval", "1s"},
+		}))
+	})
+
+	It("counts the issues relabelled by a merge or a rename", func() {
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			if repoName == "repo-2" && labelName == "type: bug" {
+				return []githubstructures.Issue{githubstructures.Issue{Number: 1}, githubstructures.Issue{Number: 2}}
+			}
+			if repoName == "repo-2" && labelName == "bug" {
+				return []githubstructures.Issue{githubstructures.Issue{Number: 1}}
+			}
+			return []githubstructures.Issue{}
+		}
+		steps := []githubstructures.LabelStep{
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", LabelName: "type: bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", LabelName: "bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-2", LabelName: "bug", NewLabelName: "type: bug"},
+		}
+
+		Expect(countRelabelled(Mocklabelsclient{}, steps, false)).To(Equal(3))
+		Expect(countRelabelled(Mocklabelsclient{}, steps, true)).To(Equal(2))
+	})
+})
@@ -0,0 +1,441 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/labelmatch"
+	"github.com/brainhubeu/issue-overseer/workcalendar"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const DEFAULT_STALE_WARNING_TEMPLATE = "This issue has had no activity for {{.Days}} days, so it has been marked as stale.{{if .CloseAfterDays}} It will be closed in {{.CloseAfterDays}} days if there is no further activity.{{end}}"
+
+const DEFAULT_ESCALATION_TEMPLATE = "{{.Mention}} this issue reported by @{{.Reporter}} {{.Age}} ago has been waiting for an answer for {{.WaitingHours}} business hours."
+
+type CalendarYaml struct {
+	TimeZone     string   `yaml:"timeZone"`
+	WorkingDays  []string `yaml:"workingDays"`
+	WorkingHours string   `yaml:"workingHours"`
+	Holidays     []string `yaml:"holidays"`
+	HolidaysFile string   `yaml:"holidaysFile"`
+}
+
+type SeverityDeadlineYaml struct {
+	Response   string `yaml:"response"`
+	Resolution string `yaml:"resolution"`
+}
+
+type EscalationYaml struct {
+	Mention  string   `yaml:"mention"`
+	Template string   `yaml:"template"`
+	Stages   []string `yaml:"stages"`
+}
+
+type StaleYaml struct {
+	Days           int    `yaml:"days"`
+	CloseAfterDays int    `yaml:"closeAfterDays"`
+	Label          string `yaml:"label"`
+	Warning        string `yaml:"warning"`
+}
+
+type ExclusiveGroupYaml struct {
+	Prefix string   `yaml:"prefix"`
+	Labels []string `yaml:"labels"`
+	Policy string   `yaml:"policy"`
+}
+
+type RepoYaml struct {
+	Calendar          string                          `yaml:"calendar"`
+	SeverityDeadlines map[string]SeverityDeadlineYaml `yaml:"severityDeadlines"`
+	OverdueLabel      string                          `yaml:"overdueLabel"`
+	Escalation        *EscalationYaml                 `yaml:"escalation"`
+	Stale             *StaleYaml                      `yaml:"stale"`
+	ExclusiveGroups   []ExclusiveGroupYaml            `yaml:"exclusiveGroups"`
+	ConflictLabel     string                          `yaml:"conflictLabel"`
+	InvalidLabel      string                          `yaml:"invalidCombinationLabel"`
+}
+
+type ManualLabelYaml struct {
+	DependencyMode string `yaml:"dependencyMode"`
+}
+
+type ConfigYaml struct {
+	LabelSeparators []string                   `yaml:"labelSeparators"`
+	ManualLabels    map[string]ManualLabelYaml `yaml:"manualLabels"`
+	Calendars       map[string]CalendarYaml    `yaml:"calendars"`
+	Defaults        RepoYaml                   `yaml:"defaults"`
+	Repos           map[string]RepoYaml        `yaml:"repos"`
+}
+
+type config struct {
+	labelSeparators []string
+	dependencyModes map[string]int
+	defaults        githubstructures.RepoConfig
+	repos           map[string]githubstructures.RepoConfig
+}
+
+var dependencyModes = map[string]int{
+	"ignore": githubstructures.ManualLabelDependencyModeEnum.IGNORE,
+	"remove": githubstructures.ManualLabelDependencyModeEnum.REMOVE,
+	"flag":   githubstructures.ManualLabelDependencyModeEnum.FLAG,
+}
+
+var exclusiveGroupPolicies = map[string]int{
+	"latest":   githubstructures.ExclusiveGroupPolicyEnum.LATEST,
+	"priority": githubstructures.ExclusiveGroupPolicyEnum.PRIORITY,
+	"flag":     githubstructures.ExclusiveGroupPolicyEnum.FLAG,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func New() *config {
+	defaults := githubstructures.RepoConfig{
+		Calendar:          workcalendar.WallClock(),
+		SeverityDeadlines: map[string]githubstructures.SeverityDeadline{},
+		OverdueLabelName:  "overdue",
+		Escalation: githubstructures.EscalationConfig{
+			Template: template.Must(template.New("escalation").Parse(DEFAULT_ESCALATION_TEMPLATE)),
+			Stages:   []time.Duration{},
+		},
+		Stale: githubstructures.StaleConfig{
+			LabelName: "stale",
+			Warning:   template.Must(template.New("stale").Parse(DEFAULT_STALE_WARNING_TEMPLATE)),
+		},
+		ExclusiveGroups:             []githubstructures.ExclusiveGroup{},
+		ConflictLabelName:           "label conflict",
+		InvalidCombinationLabelName: "invalid label combination",
+	}
+	return &config{labelmatch.DefaultSeparators, map[string]int{}, defaults, map[string]githubstructures.RepoConfig{}}
+}
+
+func Load(path string) (*config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, filepath.Dir(path))
+}
+
+func Parse(data []byte, baseDir string) (*config, error) {
+	configYaml := ConfigYaml{}
+	err := yaml.UnmarshalStrict(data, &configYaml)
+	if err != nil {
+		return nil, err
+	}
+	calendars := map[string]githubstructures.CalendarConfig{}
+	for name, calendarYaml := range configYaml.Calendars {
+		calendar, err := parseCalendar(calendarYaml, baseDir)
+		if err != nil {
+			return nil, errors.New("calendar \"" + name + "\": " + err.Error())
+		}
+		calendars[name] = calendar
+	}
+	result := New()
+	if configYaml.LabelSeparators != nil {
+		for i := 0; i < len(configYaml.LabelSeparators); i++ {
+			if strings.TrimSpace(configYaml.LabelSeparators[i]) == "" {
+				return nil, errors.New("label separator #" + strconv.Itoa(i+1) + " is blank")
+			}
+		}
+		result.labelSeparators = configYaml.LabelSeparators
+	}
+	for prefix, manualLabelYaml := range configYaml.ManualLabels {
+		dependencyMode, ok := dependencyModes[manualLabelYaml.DependencyMode]
+		if !ok {
+			return nil, errors.New("manual label \"" + prefix + "\": invalid dependency mode \"" + manualLabelYaml.DependencyMode + "\", expected ignore, remove or flag")
+		}
+		result.dependencyModes[prefix] = dependencyMode
+	}
+	result.defaults, err = parseRepo(configYaml.Defaults, result.defaults, calendars)
+	if err != nil {
+		return nil, errors.New("defaults: " + err.Error())
+	}
+	for repoName, repoYaml := range configYaml.Repos {
+		repoConfig, err := parseRepo(repoYaml, result.defaults, calendars)
+		if err != nil {
+			return nil, errors.New("repo \"" + repoName + "\": " + err.Error())
+		}
+		result.repos[repoName] = repoConfig
+	}
+	return result, nil
+}
+
+func (config *config) LabelSeparators() []string {
+	return config.labelSeparators
+}
+
+func (config *config) ManualLabelConfigs(manualLabelConfigs []githubstructures.ManualLabelConfig) ([]githubstructures.ManualLabelConfig, error) {
+	configs := []githubstructures.ManualLabelConfig{}
+	prefixes := map[string]bool{}
+	for i := 0; i < len(manualLabelConfigs); i++ {
+		manualLabelConfig := manualLabelConfigs[i]
+		dependencyMode, ok := config.dependencyModes[manualLabelConfig.Prefix]
+		if ok {
+			manualLabelConfig.DependencyMode = dependencyMode
+		}
+		configs = append(configs, manualLabelConfig)
+		prefixes[manualLabelConfig.Prefix] = true
+	}
+	for prefix := range config.dependencyModes {
+		if !prefixes[prefix] {
+			return nil, errors.New("unknown manual label \"" + prefix + "\"")
+		}
+	}
+	return configs, nil
+}
+
+func (config *config) ForRepo(repoName string) githubstructures.RepoConfig {
+	repoConfig, ok := config.repos[repoName]
+	if !ok {
+		return config.defaults
+	}
+	return repoConfig
+}
+
+func parseRepo(repoYaml RepoYaml, defaults githubstructures.RepoConfig, calendars map[string]githubstructures.CalendarConfig) (githubstructures.RepoConfig, error) {
+	repoConfig := defaults
+	if repoYaml.Calendar != "" {
+		calendar, ok := calendars[repoYaml.Calendar]
+		if !ok {
+			return repoConfig, errors.New("unknown calendar \"" + repoYaml.Calendar + "\"")
+		}
+		repoConfig.Calendar = calendar
+	}
+	if repoYaml.SeverityDeadlines != nil {
+		repoConfig.SeverityDeadlines = map[string]githubstructures.SeverityDeadline{}
+		for severity, deadlineYaml := range repoYaml.SeverityDeadlines {
+			deadline, err := parseSeverityDeadline(deadlineYaml)
+			if err != nil {
+				return repoConfig, errors.New("severity \"" + severity + "\": " + err.Error())
+			}
+			repoConfig.SeverityDeadlines[severity] = deadline
+		}
+	}
+	if repoYaml.OverdueLabel != "" {
+		repoConfig.OverdueLabelName = repoYaml.OverdueLabel
+	}
+	if repoYaml.Escalation != nil {
+		escalation, err := parseEscalation(*repoYaml.Escalation)
+		if err != nil {
+			return repoConfig, errors.New("escalation: " + err.Error())
+		}
+		repoConfig.Escalation = escalation
+	}
+	if repoYaml.Stale != nil {
+		stale, err := parseStale(*repoYaml.Stale)
+		if err != nil {
+			return repoConfig, errors.New("stale: " + err.Error())
+		}
+		repoConfig.Stale = stale
+	}
+	if repoYaml.ExclusiveGroups != nil {
+		repoConfig.ExclusiveGroups = []githubstructures.ExclusiveGroup{}
+		for i := 0; i < len(repoYaml.ExclusiveGroups); i++ {
+			group, err := parseExclusiveGroup(repoYaml.ExclusiveGroups[i])
+			if err != nil {
+				return repoConfig, errors.New("exclusive group #" + strconv.Itoa(i+1) + ": " + err.Error())
+			}
+			repoConfig.ExclusiveGroups = append(repoConfig.ExclusiveGroups, group)
+		}
+	}
+	if repoYaml.ConflictLabel != "" {
+		repoConfig.ConflictLabelName = repoYaml.ConflictLabel
+	}
+	if repoYaml.InvalidLabel != "" {
+		repoConfig.InvalidCombinationLabelName = repoYaml.InvalidLabel
+	}
+	return repoConfig, nil
+}
+
+func parseExclusiveGroup(groupYaml ExclusiveGroupYaml) (githubstructures.ExclusiveGroup, error) {
+	group := githubstructures.ExclusiveGroup{Prefix: groupYaml.Prefix, LabelNames: groupYaml.Labels}
+	if group.LabelNames == nil {
+		group.LabelNames = []string{}
+	}
+	if group.Prefix == "" && len(group.LabelNames) < 2 {
+		return group, errors.New("either a prefix or at least two labels are required")
+	}
+	policy, ok := exclusiveGroupPolicies[groupYaml.Policy]
+	if !ok {
+		return group, errors.New("invalid policy \"" + groupYaml.Policy + "\", expected latest, priority or flag")
+	}
+	if policy == githubstructures.ExclusiveGroupPolicyEnum.PRIORITY && len(group.LabelNames) == 0 {
+		return group, errors.New("the priority policy requires labels ordered from the highest priority")
+	}
+	group.Policy = policy
+	return group, nil
+}
+
+func parseStale(staleYaml StaleYaml) (githubstructures.StaleConfig, error) {
+	stale := githubstructures.StaleConfig{
+		After:      time.Duration(staleYaml.Days) * 24 * time.Hour,
+		CloseAfter: time.Duration(staleYaml.CloseAfterDays) * 24 * time.Hour,
+		LabelName:  staleYaml.Label,
+	}
+	if staleYaml.Days < 0 || staleYaml.CloseAfterDays < 0 {
+		return stale, errors.New("days must not be negative")
+	}
+	if staleYaml.CloseAfterDays > 0 && staleYaml.Days == 0 {
+		return stale, errors.New("closeAfterDays requires days")
+	}
+	if stale.LabelName == "" {
+		stale.LabelName = "stale"
+	}
+	warningText := staleYaml.Warning
+	if warningText == "" {
+		warningText = DEFAULT_STALE_WARNING_TEMPLATE
+	}
+	warning, err := template.New("stale").Option("missingkey=error").Parse(warningText)
+	if err != nil {
+		return stale, err
+	}
+	err = warning.Execute(&bytes.Buffer{}, githubstructures.StaleTemplateData{})
+	if err != nil {
+		return stale, err
+	}
+	stale.Warning = warning
+	return stale, nil
+}
+
+func parseEscalation(escalationYaml EscalationYaml) (githubstructures.EscalationConfig, error) {
+	escalation := githubstructures.EscalationConfig{Mention: escalationYaml.Mention, Stages: []time.Duration{}}
+	templateText := escalationYaml.Template
+	if templateText == "" {
+		templateText = DEFAULT_ESCALATION_TEMPLATE
+	}
+	escalationTemplate, err := template.New("escalation").Option("missingkey=error").Parse(templateText)
+	if err != nil {
+		return escalation, err
+	}
+	err = escalationTemplate.Execute(&bytes.Buffer{}, githubstructures.EscalationTemplateData{})
+	if err != nil {
+		return escalation, err
+	}
+	escalation.Template = escalationTemplate
+	for i := 0; i < len(escalationYaml.Stages); i++ {
+		stage, err := parseBusinessDuration(escalationYaml.Stages[i])
+		if err != nil {
+			return escalation, err
+		}
+		if stage == 0 || i > 0 && stage <= escalation.Stages[i-1] {
+			return escalation, errors.New("stages must be positive and increasing")
+		}
+		escalation.Stages = append(escalation.Stages, stage)
+	}
+	if len(escalation.Stages) > 0 && escalation.Mention == "" {
+		return escalation, errors.New("mention is required")
+	}
+	return escalation, nil
+}
+
+func parseBusinessDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, errors.New("invalid duration \"" + value + "\", expected e.g. 4h or 90m")
+	}
+	return duration, nil
+}
+
+func parseSeverityDeadline(deadlineYaml SeverityDeadlineYaml) (githubstructures.SeverityDeadline, error) {
+	response, err := parseBusinessDuration(deadlineYaml.Response)
+	if err != nil {
+		return githubstructures.SeverityDeadline{}, err
+	}
+	resolution, err := parseBusinessDuration(deadlineYaml.Resolution)
+	if err != nil {
+		return githubstructures.SeverityDeadline{}, err
+	}
+	return githubstructures.SeverityDeadline{Response: response, Resolution: resolution}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("invalid time \"" + value + "\", expected HH:MM")
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+func parseCalendar(calendarYaml CalendarYaml, baseDir string) (githubstructures.CalendarConfig, error) {
+	calendar := workcalendar.WallClock()
+	if calendarYaml.TimeZone != "" {
+		location, err := time.LoadLocation(calendarYaml.TimeZone)
+		if err != nil {
+			return calendar, errors.New("invalid time zone \"" + calendarYaml.TimeZone + "\"")
+		}
+		calendar.Location = location
+	}
+	if calendarYaml.WorkingDays != nil {
+		calendar.WorkingDays = []time.Weekday{}
+		for i := 0; i < len(calendarYaml.WorkingDays); i++ {
+			weekday, ok := weekdays[strings.ToLower(calendarYaml.WorkingDays[i])]
+			if !ok {
+				return calendar, errors.New("invalid working day \"" + calendarYaml.WorkingDays[i] + "\"")
+			}
+			calendar.WorkingDays = append(calendar.WorkingDays, weekday)
+		}
+		if len(calendar.WorkingDays) == 0 {
+			return calendar, errors.New("at least one working day is required")
+		}
+	}
+	if calendarYaml.WorkingHours != "" {
+		hours := strings.Split(calendarYaml.WorkingHours, "-")
+		if len(hours) != 2 {
+			return calendar, errors.New("invalid working hours \"" + calendarYaml.WorkingHours + "\", expected HH:MM-HH:MM")
+		}
+		start, err := parseClock(hours[0])
+		if err != nil {
+			return calendar, err
+		}
+		end, err := parseClock(hours[1])
+		if err != nil {
+			return calendar, err
+		}
+		if end <= start {
+			return calendar, errors.New("working hours \"" + calendarYaml.WorkingHours + "\" must end after they start")
+		}
+		calendar.WorkingHoursStart = start
+		calendar.WorkingHoursEnd = end
+	}
+	for i := 0; i < len(calendarYaml.Holidays); i++ {
+		holiday, err := time.Parse("2006-01-02", calendarYaml.Holidays[i])
+		if err != nil {
+			return calendar, errors.New("invalid holiday \"" + calendarYaml.Holidays[i] + "\", expected YYYY-MM-DD")
+		}
+		calendar.Holidays = append(calendar.Holidays, holiday)
+	}
+	if calendarYaml.HolidaysFile != "" {
+		path := calendarYaml.HolidaysFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		holidays, err := workcalendar.LoadHolidays(path)
+		if err != nil {
+			return calendar, err
+		}
+		calendar.Holidays = append(calendar.Holidays, holidays...)
+	}
+	return calendar, nil
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/workcalendar"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "config")
+}
+
+var _ = Describe("config", func() {
+	It("uses defaults", func() {
+		config := New()
+
+		repoConfig := config.ForRepo("repo-1")
+
+		Expect(repoConfig.Calendar).To(Equal(workcalendar.WallClock()))
+		Expect(repoConfig.SeverityDeadlines).To(Equal(map[string]githubstructures.SeverityDeadline{}))
+		Expect(repoConfig.OverdueLabelName).To(Equal("overdue"))
+		Expect(repoConfig.Escalation.Mention).To(Equal(""))
+		Expect(repoConfig.Escalation.Stages).To(Equal([]time.Duration{}))
+		Expect(repoConfig.Stale.After).To(Equal(time.Duration(0)))
+		Expect(repoConfig.Stale.LabelName).To(Equal("stale"))
+		Expect(repoConfig.ExclusiveGroups).To(Equal([]githubstructures.ExclusiveGroup{}))
+		Expect(repoConfig.ConflictLabelName).To(Equal("label conflict"))
+		Expect(repoConfig.InvalidCombinationLabelName).To(Equal("invalid label combination"))
+		Expect(config.LabelSeparators()).To(Equal([]string{":", " - "}))
+	})
+
+	It("parses calendars and assigns them to repos", func() {
+		config, err := Parse([]byte(`
+calendars:
+  emea:
+    timeZone: Europe/Warsaw
+    workingDays: [monday, tuesday, wednesday, thursday, friday]
+    workingHours: 09:00-17:00
+    holidays: ["2020-12-25"]
+  us:
+    timeZone: America/New_York
+    workingHours: 08:30-24:00
+defaults:
+  calendar: emea
+repos:
+  repo-us:
+    calendar: us
+  repo-default: {}
+`), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		warsaw, _ := time.LoadLocation("Europe/Warsaw")
+		newYork, _ := time.LoadLocation("America/New_York")
+		emea := githubstructures.CalendarConfig{
+			Location:          warsaw,
+			WorkingDays:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			WorkingHoursStart: 9 * time.Hour,
+			WorkingHoursEnd:   17 * time.Hour,
+			Holidays:          []time.Time{time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)},
+		}
+		us := workcalendar.WallClock()
+		us.Location = newYork
+		us.WorkingHoursStart = 8*time.Hour + 30*time.Minute
+		Expect(config.ForRepo("repo-1").Calendar).To(Equal(emea))
+		Expect(config.ForRepo("repo-default").Calendar).To(Equal(emea))
+		Expect(config.ForRepo("repo-us").Calendar).To(Equal(us))
+	})
+
+	It("parses severity deadlines", func() {
+		config, err := Parse([]byte(`
+defaults:
+  severityDeadlines:
+    critical:
+      response: 4h
+      resolution: 40h
+    major:
+      response: 90m
+repos:
+  repo-1:
+    severityDeadlines: {}
+    overdueLabel: late
+`), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ForRepo("repo-2").SeverityDeadlines).To(Equal(map[string]githubstructures.SeverityDeadline{
+			"critical": githubstructures.SeverityDeadline{Response: 4 * time.Hour, Resolution: 40 * time.Hour},
+			"major":    githubstructures.SeverityDeadline{Response: 90 * time.Minute},
+		}))
+		Expect(config.ForRepo("repo-2").OverdueLabelName).To(Equal("overdue"))
+		Expect(config.ForRepo("repo-1").SeverityDeadlines).To(Equal(map[string]githubstructures.SeverityDeadline{}))
+		Expect(config.ForRepo("repo-1").OverdueLabelName).To(Equal("late"))
+	})
+
+	It("rejects invalid severity deadlines", func() {
+		_, err := Parse([]byte("defaults:\n  severityDeadlines:\n    critical:\n      response: 4 hours\n"), ".")
+		Expect(err).To(MatchError("defaults: severity \"critical\": invalid duration \"4 hours\", expected e.g. 4h or 90m"))
+
+		_, err = Parse([]byte("defaults:\n  severityDeadlines:\n    critical:\n      resolution: -4h\n"), ".")
+		Expect(err).To(MatchError("defaults: severity \"critical\": invalid duration \"-4h\", expected e.g. 4h or 90m"))
+	})
+
+	It("parses escalations", func() {
+		config, err := Parse([]byte(`
+defaults:
+  escalation:
+    mention: "@org/on-call"
+    stages: [8h, 24h]
+repos:
+  repo-1:
+    escalation:
+      mention: "@someone"
+      template: "{{.Mention}} please look at {{.Title}}"
+      stages: [4h]
+`), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ForRepo("repo-2").Escalation.Mention).To(Equal("@org/on-call"))
+		Expect(config.ForRepo("repo-2").Escalation.Stages).To(Equal([]time.Duration{8 * time.Hour, 24 * time.Hour}))
+		Expect(config.ForRepo("repo-2").Escalation.Template.Root.String()).To(Equal(DEFAULT_ESCALATION_TEMPLATE))
+		Expect(config.ForRepo("repo-1").Escalation.Mention).To(Equal("@someone"))
+		Expect(config.ForRepo("repo-1").Escalation.Stages).To(Equal([]time.Duration{4 * time.Hour}))
+		Expect(config.ForRepo("repo-1").Escalation.Template.Root.String()).To(Equal("{{.Mention}} please look at {{.Title}}"))
+	})
+
+	It("rejects invalid escalations", func() {
+		invalidEscalations := map[string]string{
+			"{mention: \"@a\", template: \"{{.Mention\"}": "defaults: escalation: template: escalation:1: unclosed action",
+			"{mention: \"@a\", template: \"{{.Nope}}\"}":  "defaults: escalation: template: escalation:1:2: executing \"escalation\" at <.Nope>: can't evaluate field Nope in type githubstructures.EscalationTemplateData",
+			"{mention: \"@a\", stages: [1 day]}":          "defaults: escalation: invalid duration \"1 day\", expected e.g. 4h or 90m",
+			"{mention: \"@a\", stages: [0h]}":             "defaults: escalation: stages must be positive and increasing",
+			"{mention: \"@a\", stages: [8h, 4h]}":         "defaults: escalation: stages must be positive and increasing",
+			"{stages: [8h]}":                              "defaults: escalation: mention is required",
+		}
+		for escalationYaml, message := range invalidEscalations {
+			_, err := Parse([]byte("defaults:\n  escalation: "+escalationYaml+"\n"), ".")
+
+			Expect(err).To(MatchError(message))
+		}
+	})
+
+	It("parses stale rules", func() {
+		config, err := Parse([]byte(`
+defaults:
+  stale:
+    days: 30
+repos:
+  repo-1:
+    stale:
+      days: 60
+      closeAfterDays: 7
+      label: abandoned
+      warning: "@{{.Reporter}} are you still there?"
+`), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		defaultStale := config.ForRepo("repo-2").Stale
+		Expect(defaultStale.After).To(Equal(30 * 24 * time.Hour))
+		Expect(defaultStale.CloseAfter).To(Equal(time.Duration(0)))
+		Expect(defaultStale.LabelName).To(Equal("stale"))
+		Expect(defaultStale.Warning.Root.String()).To(Equal(DEFAULT_STALE_WARNING_TEMPLATE))
+		repoStale := config.ForRepo("repo-1").Stale
+		Expect(repoStale.After).To(Equal(60 * 24 * time.Hour))
+		Expect(repoStale.CloseAfter).To(Equal(7 * 24 * time.Hour))
+		Expect(repoStale.LabelName).To(Equal("abandoned"))
+		Expect(repoStale.Warning.Root.String()).To(Equal("@{{.Reporter}} are you still there?"))
+	})
+
+	It("rejects invalid stale rules", func() {
+		invalidStaleRules := map[string]string{
+			"{days: -1}":                           "defaults: stale: days must not be negative",
+			"{closeAfterDays: 7}":                  "defaults: stale: closeAfterDays requires days",
+			"{days: 1, warning: \"{{.Reporter\"}":  "defaults: stale: template: stale:1: unclosed action",
+			"{days: 1, warning: \"{{.Mention}}\"}": "defaults: stale: template: stale:1:2: executing \"stale\" at <.Mention>: can't evaluate field Mention in type githubstructures.StaleTemplateData",
+		}
+		for staleYaml, message := range invalidStaleRules {
+			_, err := Parse([]byte("defaults:\n  stale: "+staleYaml+"\n"), ".")
+
+			Expect(err).To(MatchError(message))
+		}
+	})
+
+	It("parses exclusive groups", func() {
+		config, err := Parse([]byte(`
+defaults:
+  exclusiveGroups:
+    - prefix: severity
+      policy: latest
+    - labels: ["tested & fails", "tested & works"]
+      policy: priority
+    - prefix: type
+      labels: ["type: bug"]
+      policy: flag
+  conflictLabel: conflict
+`), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ForRepo("repo-1").ExclusiveGroups).To(Equal([]githubstructures.ExclusiveGroup{
+			githubstructures.ExclusiveGroup{Prefix: "severity", LabelNames: []string{}, Policy: githubstructures.ExclusiveGroupPolicyEnum.LATEST},
+			githubstructures.ExclusiveGroup{LabelNames: []string{"tested & fails", "tested & works"}, Policy: githubstructures.ExclusiveGroupPolicyEnum.PRIORITY},
+			githubstructures.ExclusiveGroup{Prefix: "type", LabelNames: []string{"type: bug"}, Policy: githubstructures.ExclusiveGroupPolicyEnum.FLAG},
+		}))
+		Expect(config.ForRepo("repo-1").ConflictLabelName).To(Equal("conflict"))
+	})
+
+	It("parses the invalid combination label", func() {
+		config, err := Parse([]byte(`
+repos:
+  repo-1:
+    invalidCombinationLabel: invalid
+`), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ForRepo("repo-1").InvalidCombinationLabelName).To(Equal("invalid"))
+		Expect(config.ForRepo("repo-2").InvalidCombinationLabelName).To(Equal("invalid label combination"))
+	})
+
+	It("parses the dependency modes of manual labels", func() {
+		config, err := Parse([]byte(`
+manualLabels:
+  severity:
+    dependencyMode: remove
+`), ".")
+		Expect(err).NotTo(HaveOccurred())
+
+		manualLabelConfigs, err := config.ManualLabelConfigs([]githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.IGNORE},
+			githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.FLAG},
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manualLabelConfigs).To(Equal([]githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.IGNORE},
+			githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.REMOVE},
+		}))
+
+		_, err = config.ManualLabelConfigs([]githubstructures.ManualLabelConfig{githubstructures.ManualLabelConfig{Prefix: "type"}})
+
+		Expect(err).To(MatchError("unknown manual label \"severity\""))
+	})
+
+	It("rejects invalid dependency modes", func() {
+		_, err := Parse([]byte("manualLabels:\n  severity:\n    dependencyMode: delete\n"), ".")
+
+		Expect(err).To(MatchError("manual label \"severity\": invalid dependency mode \"delete\", expected ignore, remove or flag"))
+	})
+
+	It("rejects invalid exclusive groups", func() {
+		invalidGroups := map[string]string{
+			"{labels: [a], policy: flag}":          "defaults: exclusive group #1: either a prefix or at least two labels are required",
+			"{prefix: severity, policy: random}":   "defaults: exclusive group #1: invalid policy \"random\", expected latest, priority or flag",
+			"{prefix: severity, policy: priority}": "defaults: exclusive group #1: the priority policy requires labels ordered from the highest priority",
+		}
+		for groupYaml, message := range invalidGroups {
+			_, err := Parse([]byte("defaults:\n  exclusiveGroups: ["+groupYaml+"]\n"), ".")
+
+			Expect(err).To(MatchError(message))
+		}
+	})
+
+	It("parses label separators", func() {
+		config, err := Parse([]byte("labelSeparators: [':', ' | ']\n"), ".")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.LabelSeparators()).To(Equal([]string{":", " | "}))
+	})
+
+	It("rejects blank label separators", func() {
+		_, err := Parse([]byte("labelSeparators: [':', ' ']\n"), ".")
+
+		Expect(err).To(MatchError("label separator #2 is blank"))
+	})
+
+	It("loads holidays relative to the config file", func() {
+		dir, err := ioutil.TempDir("", "config")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		Expect(ioutil.WriteFile(filepath.Join(dir, "holidays.yml"), []byte("- date: 2020-12-25\n"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte("calendars:\n  main:\n    holidaysFile: holidays.yml\ndefaults:\n  calendar: main\n"), 0644)).To(Succeed())
+
+		config, err := Load(filepath.Join(dir, "config.yml"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ForRepo("repo-1").Calendar.Holidays).To(Equal([]time.Time{time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)}))
+	})
+
+	It("fails for a missing file", func() {
+		_, err := Load("/non/existent/config.yml")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects unknown fields", func() {
+		_, err := Parse([]byte("calendar: main\n"), ".")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid calendars", func() {
+		invalidCalendars := map[string]string{
+			"timeZone: Mars/Olympus":   "calendar \"main\": invalid time zone \"Mars/Olympus\"",
+			"workingDays: [funday]":    "calendar \"main\": invalid working day \"funday\"",
+			"workingDays: []":          "calendar \"main\": at least one working day is required",
+			"workingHours: 9-17-18":    "calendar \"main\": invalid working hours \"9-17-18\", expected HH:MM-HH:MM",
+			"workingHours: 9am-17:00":  "calendar \"main\": invalid time \"9am\", expected HH:MM",
+			"workingHours: 09:00-5pm":  "calendar \"main\": invalid time \"5pm\", expected HH:MM",
+			"workingHours: 17:00-9:00": "calendar \"main\": working hours \"17:00-9:00\" must end after they start",
+			"workingHours: 9:00-9:00":  "calendar \"main\": working hours \"9:00-9:00\" must end after they start",
+			"holidays: [25.12.2020]":   "calendar \"main\": invalid holiday \"25.12.2020\", expected YYYY-MM-DD",
+			"holidaysFile: nope.yml":   "calendar \"main\": open nope.yml: no such file or directory",
+		}
+		for calendarYaml, message := range invalidCalendars {
+			_, err := Parse([]byte("calendars:\n  main:\n    "+calendarYaml+"\n"), ".")
+
+			Expect(err).To(MatchError(message))
+		}
+	})
+
+	It("rejects unknown calendars", func() {
+		_, err := Parse([]byte("defaults:\n  calendar: main\n"), ".")
+		Expect(err).To(MatchError("defaults: unknown calendar \"main\""))
+
+		_, err = Parse([]byte("repos:\n  repo-1:\n    calendar: main\n"), ".")
+		Expect(err).To(MatchError("repo \"repo-1\": unknown calendar \"main\""))
+	})
+})
@@ -4,13 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/logging"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type githubclient struct {
@@ -24,9 +26,14 @@ type Repository struct {
 	Name     string `json:"name"`
 }
 
+type InstallationRepositories struct {
+	TotalCount int `json:"total_count"`
+}
+
 type Label struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
 }
 
 type CommentAuthor struct {
@@ -36,6 +43,17 @@ type CommentAuthor struct {
 type Comment struct {
 	AuthorAssociation string        `json:"authorAssociation"`
 	Author            CommentAuthor `json:"author"`
+	Body              string        `json:"body"`
+	CreatedAt         time.Time     `json:"createdAt"`
+}
+
+type LabeledEvent struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Label     Label     `json:"label"`
+}
+
+type TimelineItems struct {
+	Nodes []LabeledEvent `json:"nodes"`
 }
 
 type LabelEdge struct {
@@ -55,12 +73,22 @@ type Comments struct {
 }
 
 type Issue struct {
-	Title             string   `json:"title"`
-	Url               string   `json:"url"`
-	Number            int      `json:"number"`
-	AuthorAssociation string   `json:"authorAssociation"`
-	Labels            Labels   `json:"labels"`
-	Comments          Comments `json:"comments"`
+	Title             string        `json:"title"`
+	Url               string        `json:"url"`
+	Number            int           `json:"number"`
+	AuthorAssociation string        `json:"authorAssociation"`
+	Author            CommentAuthor `json:"author"`
+	CreatedAt         time.Time     `json:"createdAt"`
+	Labels            Labels        `json:"labels"`
+	TimelineItems     TimelineItems `json:"timelineItems"`
+	Comments          Comments      `json:"comments"`
+}
+
+type RestIssue struct {
+	Title       string    `json:"title"`
+	Number      int       `json:"number"`
+	Labels      []Label   `json:"labels"`
+	PullRequest *struct{} `json:"pull_request"`
 }
 
 type IssueEdge struct {
@@ -78,6 +106,46 @@ type Issues struct {
 	} `json:"data"`
 }
 
+type TotalCount struct {
+	TotalCount int `json:"totalCount"`
+}
+
+type LabelUsageNode struct {
+	Name         string     `json:"name"`
+	Color        string     `json:"color"`
+	Description  string     `json:"description"`
+	Issues       TotalCount `json:"issues"`
+	PullRequests TotalCount `json:"pullRequests"`
+}
+
+type LabelUsages struct {
+	Data struct {
+		Repository struct {
+			Labels struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []LabelUsageNode `json:"nodes"`
+			} `json:"labels"`
+		} `json:"repository"`
+	} `json:"data"`
+}
+
+type SingleIssue struct {
+	Data struct {
+		Repository struct {
+			Issue *Issue `json:"issue"`
+		} `json:"repository"`
+	} `json:"data"`
+}
+
+type IssueGraphqlVariables struct {
+	Organization string `json:"organization"`
+	RepoName     string `json:"repoName"`
+	Number       int    `json:"number"`
+}
+
 type GraphqlVariables struct {
 	Organization string  `json:"organization"`
 	RepoName     string  `json:"repoName"`
@@ -85,18 +153,32 @@ type GraphqlVariables struct {
 }
 
 type GraphqlRequestBody struct {
-	Variables GraphqlVariables `json:"variables"`
-	Query     string           `json:"query"`
+	Variables interface{} `json:"variables"`
+	Query     string      `json:"query"`
 }
 
 type AddLabelRequestBody struct {
 	Labels []string `json:"labels"`
 }
 
+type CommentRequestBody struct {
+	Body string `json:"body"`
+}
+
+type IssueStateRequestBody struct {
+	State string `json:"state"`
+}
+
 type LabelRenameRequestBody struct {
 	NewName string `json:"new_name"`
 }
 
+type LabelUpdateRequestBody struct {
+	NewName     string `json:"new_name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
 type GithubError struct {
 	Value    string `json:"value"`
 	Resource string `json:"resource"`
@@ -117,7 +199,10 @@ func New(organization string, token string) *githubclient {
 
 func (githubClient *githubclient) incrementRequestNumber() {
 	githubClient.RequestsNumber++
-	log.Println("(v 1.0.8) request to GitHub #", githubClient.RequestsNumber)
+}
+
+func (githubClient *githubclient) RequestsCount() int {
+	return githubClient.RequestsNumber
 }
 
 func createJson(data interface{}) io.Reader {
@@ -126,51 +211,83 @@ func createJson(data interface{}) io.Reader {
 	}
 	jsonValue, err := json.Marshal(data)
 	if err != nil {
-		log.Fatalln(err)
+		logging.Fatal(logging.Fields{}, "cannot encode the request", err)
 	}
 	return bytes.NewBuffer(jsonValue)
 }
 
-func (githubClient *githubclient) request(method string, url string, source interface{}, isValid func(statusCode int, errorBody ErrorResponseBody) bool, requestBody interface{}) {
-	log.Println("request", method, url, requestBody)
+func (githubClient *githubclient) request(method string, url string, source interface{}, isValid func(statusCode int, errorBody ErrorResponseBody) bool, requestBody interface{}) *http.Response {
 	client := &http.Client{}
 	githubClient.incrementRequestNumber()
+	logging.Debug(logging.Fields{}, "request to GitHub #", githubClient.RequestsNumber, method, url)
 	jsonReader := createJson(requestBody)
 	req, err := http.NewRequest(method, url, jsonReader)
 	if err != nil {
-		log.Fatalln(err)
+		panic(err)
 	}
 	req.Header.Add("Authorization", "token "+githubClient.Token)
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatalln(err)
+		panic(err)
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Fatalln(err)
+		panic(err)
 	}
 	if source != nil {
 		err = json.Unmarshal(body, &source)
 		if err != nil {
-			log.Fatalln(err)
+			panic(err)
 		}
 	}
 	errorBody := ErrorResponseBody{}
 	if resp.StatusCode >= 400 {
 		err = json.Unmarshal(body, &errorBody)
 		if err != nil {
-			log.Fatalln(err)
+			panic(err)
 		}
 	} else {
 		err = json.Unmarshal(body, &source)
 		if err != nil {
-			log.Fatalln(err)
+			panic(err)
 		}
 	}
 	if !isValid(resp.StatusCode, errorBody) {
-		log.Fatalln(resp.Status, string(body))
+		panic(resp.Status + " " + string(body))
 	}
+	return resp
+}
+
+func (githubClient *githubclient) FindTokenInfo() githubstructures.TokenInfo {
+	user := CommentAuthor{}
+	resp := githubClient.request(
+		http.MethodGet,
+		"https://api.github.com/user",
+		&user,
+		func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 || statusCode == 403 },
+		nil,
+	)
+	if resp.StatusCode == 200 {
+		scopes := []string{}
+		scopesHeader := strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",")
+		for i := 0; i < len(scopesHeader); i++ {
+			scope := strings.TrimSpace(scopesHeader[i])
+			if scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		return githubstructures.TokenInfo{Login: user.Login, Scopes: scopes}
+	}
+	installation := InstallationRepositories{}
+	githubClient.request(
+		http.MethodGet,
+		"https://api.github.com/installation/repositories?per_page=1",
+		&installation,
+		func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 },
+		nil,
+	)
+	return githubstructures.TokenInfo{IsApp: true, Scopes: []string{}, RepositoriesCount: installation.TotalCount}
 }
 
 func (githubClient *githubclient) FindRepos() []string {
@@ -201,20 +318,27 @@ func (githubClient *githubclient) FindRepos() []string {
 
 func (githubClient *githubclient) FindLabels(repoName string) []githubstructures.Label {
 	labels := []githubstructures.Label{}
-	githubClient.request(
-		http.MethodGet,
-		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels",
-		&labels,
-		func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 },
-		nil,
-	)
+	for page := 1; ; page += 1 {
+		pageLabels := []githubstructures.Label{}
+		githubClient.request(
+			http.MethodGet,
+			"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels?per_page=100&page="+strconv.Itoa(page),
+			&pageLabels,
+			func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 },
+			nil,
+		)
+		if len(pageLabels) == 0 {
+			break
+		}
+		labels = append(labels, pageLabels...)
+	}
 	return labels
 }
 
 func (githubClient *githubclient) DeleteLabel(repoName string, labelName string) {
 	githubClient.request(
 		http.MethodDelete,
-		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels/"+labelName,
+		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels/"+url.PathEscape(labelName),
 		nil,
 		func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 204 },
 		nil,
@@ -222,7 +346,7 @@ func (githubClient *githubclient) DeleteLabel(repoName string, labelName string)
 }
 
 func (githubClient *githubclient) CreateLabel(repoName string, label githubstructures.Label) {
-	labelToCreate := Label{Name: label.Name, Color: label.Color}
+	labelToCreate := Label{Name: label.Name, Color: label.Color, Description: label.Description}
 	githubClient.request(
 		http.MethodPost,
 		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels",
@@ -238,7 +362,20 @@ func (githubClient *githubclient) RenameLabel(repoName string, oldLabelName stri
 	requestBody := LabelRenameRequestBody{NewName: newLabelName}
 	githubClient.request(
 		http.MethodPatch,
-		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels/"+oldLabelName,
+		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels/"+url.PathEscape(oldLabelName),
+		nil,
+		func(statusCode int, errorBody ErrorResponseBody) bool {
+			return statusCode == 200
+		},
+		requestBody,
+	)
+}
+
+func (githubClient *githubclient) UpdateLabel(repoName string, labelName string, label githubstructures.Label) {
+	requestBody := LabelUpdateRequestBody{NewName: label.Name, Color: label.Color, Description: label.Description}
+	githubClient.request(
+		http.MethodPatch,
+		"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/labels/"+url.PathEscape(labelName),
 		nil,
 		func(statusCode int, errorBody ErrorResponseBody) bool {
 			return statusCode == 200
@@ -248,7 +385,7 @@ func (githubClient *githubclient) RenameLabel(repoName string, oldLabelName stri
 }
 
 func (githubClient *githubclient) RemoveLabel(issueUrl string, labelName string) {
-	url := strings.Replace(issueUrl, "https://github.com", "https://api.github.com/repos", 1) + "/labels/" + labelName
+	url := strings.Replace(issueUrl, "https://github.com", "https://api.github.com/repos", 1) + "/labels/" + url.PathEscape(labelName)
 	githubClient.request(
 		http.MethodDelete,
 		url,
@@ -274,10 +411,40 @@ func (githubClient *githubclient) AddLabel(issueUrl string, labelName string) {
 	)
 }
 
+func (githubClient *githubclient) CreateComment(issueUrl string, body string) {
+	requestBody := CommentRequestBody{Body: body}
+	url := strings.Replace(issueUrl, "https://github.com", "https://api.github.com/repos", 1) + "/comments"
+	githubClient.request(
+		http.MethodPost,
+		url,
+		nil,
+		func(statusCode int, errorBody ErrorResponseBody) bool {
+			return statusCode == 201
+		},
+		requestBody,
+	)
+}
+
+func (githubClient *githubclient) CloseIssue(issueUrl string) {
+	requestBody := IssueStateRequestBody{State: "closed"}
+	url := strings.Replace(issueUrl, "https://github.com", "https://api.github.com/repos", 1)
+	githubClient.request(
+		http.MethodPatch,
+		url,
+		nil,
+		func(statusCode int, errorBody ErrorResponseBody) bool {
+			return statusCode == 200
+		},
+		requestBody,
+	)
+}
+
 func transformDataIntoIssue(issueData Issue) githubstructures.Issue {
 	labelsCount := len(issueData.Labels.Edges)
 	commentsCount := len(issueData.Comments.Edges)
+	labelEventsCount := len(issueData.TimelineItems.Nodes)
 	labels := make([]githubstructures.Label, labelsCount)
+	labelEvents := make([]githubstructures.LabelEvent, labelEventsCount)
 	comments := make([]githubstructures.Comment, commentsCount)
 	for i := 0; i < labelsCount; i++ {
 		labelData := issueData.Labels.Edges[i].Node
@@ -286,11 +453,20 @@ func transformDataIntoIssue(issueData Issue) githubstructures.Issue {
 			Color: labelData.Color,
 		}
 	}
+	for i := 0; i < labelEventsCount; i++ {
+		labelEventData := issueData.TimelineItems.Nodes[i]
+		labelEvents[i] = githubstructures.LabelEvent{
+			LabelName: labelEventData.Label.Name,
+			CreatedAt: labelEventData.CreatedAt,
+		}
+	}
 	for i := 0; i < commentsCount; i++ {
 		commentData := issueData.Comments.Edges[i].Node
 		comments[i] = githubstructures.Comment{
 			AuthorAssociation: commentData.AuthorAssociation,
 			AuthorLogin:       commentData.Author.Login,
+			Body:              commentData.Body,
+			CreatedAt:         commentData.CreatedAt,
 		}
 	}
 
@@ -299,25 +475,23 @@ func transformDataIntoIssue(issueData Issue) githubstructures.Issue {
 		Url:               issueData.Url,
 		Number:            issueData.Number,
 		AuthorAssociation: issueData.AuthorAssociation,
+		AuthorLogin:       issueData.Author.Login,
+		CreatedAt:         issueData.CreatedAt,
 		Labels:            labels,
+		LabelEvents:       labelEvents,
 		Comments:          comments,
 	}
 }
 
-func (githubClient *githubclient) FindIssues(repoName string) []githubstructures.Issue {
-	cursor := (*string)(nil)
-	result := []githubstructures.Issue{}
-	for {
-		query := `query ($organization: String!, $repoName: String!, $cursor: String) {
-	  repository(owner: $organization, name: $repoName) {
-		issues(first:20, after: $cursor, states:OPEN) {
-		  edges {
-			cursor
-			node {
+const issueFields = `
 			  title
 			  url
 			  number
 			  authorAssociation
+			  author {
+				login
+			  }
+			  createdAt
 				labels (first:100) {
 				  edges {
 					node {
@@ -325,17 +499,40 @@ func (githubClient *githubclient) FindIssues(repoName string) []githubstructures
 					}
 				  }
 				}
+			  timelineItems(last:100, itemTypes:[LABELED_EVENT]) {
+				nodes {
+				  ... on LabeledEvent {
+					createdAt
+					label {
+					  name
+					}
+				  }
+				}
+			  }
 			  comments(last:100) {
 				edges {
 				  node {
-					bodyText
+					body
 					authorAssociation
 					author {
 					  login
 					}
+					createdAt
 				  }
 				}
-			  }
+			  }`
+
+func (githubClient *githubclient) FindIssues(repoName string) []githubstructures.Issue {
+	cursor := (*string)(nil)
+	result := []githubstructures.Issue{}
+	for {
+		query := `query ($organization: String!, $repoName: String!, $cursor: String) {
+	  repository(owner: $organization, name: $repoName) {
+		issues(first:20, after: $cursor, states:OPEN) {
+		  edges {
+			cursor
+			node {
+` + issueFields + `
 			}
 		  }
 		}
@@ -363,3 +560,130 @@ func (githubClient *githubclient) FindIssues(repoName string) []githubstructures
 	}
 	return result
 }
+
+func (githubClient *githubclient) FindIssue(repoName string, number int) (githubstructures.Issue, bool) {
+	query := `query ($organization: String!, $repoName: String!, $number: Int!) {
+	  repository(owner: $organization, name: $repoName) {
+		issue(number: $number) {` + issueFields + `}
+	  }
+	}`
+	graphqlVariables := IssueGraphqlVariables{Organization: githubClient.Organization, RepoName: repoName, Number: number}
+	graphqlRequestBody := GraphqlRequestBody{Variables: graphqlVariables, Query: query}
+	issueData := SingleIssue{}
+	githubClient.request(
+		http.MethodPost,
+		"https://api.github.com/graphql",
+		&issueData,
+		func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 },
+		graphqlRequestBody,
+	)
+	if issueData.Data.Repository.Issue == nil {
+		return githubstructures.Issue{}, false
+	}
+	return transformDataIntoIssue(*issueData.Data.Repository.Issue), true
+}
+
+func (githubClient *githubclient) findRestIssues(repoName string, query string) []githubstructures.Issue {
+	result := []githubstructures.Issue{}
+	for page := 1; ; page += 1 {
+		issuesData := []RestIssue{}
+		githubClient.request(
+			http.MethodGet,
+			"https://api.github.com/repos/"+githubClient.Organization+"/"+repoName+"/issues?state=all&per_page=100"+query+"&page="+strconv.Itoa(page),
+			&issuesData,
+			func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 },
+			nil,
+		)
+		if len(issuesData) == 0 {
+			break
+		}
+		for i := 0; i < len(issuesData); i++ {
+			issueData := issuesData[i]
+			labels := make([]githubstructures.Label, len(issueData.Labels))
+			for j := 0; j < len(issueData.Labels); j++ {
+				labels[j] = githubstructures.Label{
+					Name:        issueData.Labels[j].Name,
+					Color:       issueData.Labels[j].Color,
+					Description: issueData.Labels[j].Description,
+				}
+			}
+			result = append(result, githubstructures.Issue{
+				Title:         issueData.Title,
+				Url:           "https://github.com/" + githubClient.Organization + "/" + repoName + "/issues/" + strconv.Itoa(issueData.Number),
+				Number:        issueData.Number,
+				IsPullRequest: issueData.PullRequest != nil,
+				Labels:        labels,
+			})
+		}
+	}
+	return result
+}
+
+func (githubClient *githubclient) FindIssuesByLabel(repoName string, labelName string) []githubstructures.Issue {
+	issues := githubClient.FindAllIssues(repoName)
+	result := []githubstructures.Issue{}
+	for i := 0; i < len(issues); i++ {
+		for j := 0; j < len(issues[i].Labels); j++ {
+			if strings.EqualFold(issues[i].Labels[j].Name, labelName) {
+				result = append(result, issues[i])
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (githubClient *githubclient) FindAllIssues(repoName string) []githubstructures.Issue {
+	return githubClient.findRestIssues(repoName, "")
+}
+
+func (githubClient *githubclient) FindLabelUsages(repoName string) []githubstructures.LabelUsage {
+	cursor := (*string)(nil)
+	result := []githubstructures.LabelUsage{}
+	for {
+		query := `query ($organization: String!, $repoName: String!, $cursor: String) {
+	  repository(owner: $organization, name: $repoName) {
+		labels(first:100, after: $cursor) {
+		  pageInfo {
+			hasNextPage
+			endCursor
+		  }
+		  nodes {
+			name
+			color
+			description
+			issues {
+			  totalCount
+			}
+			pullRequests {
+			  totalCount
+			}
+		  }
+		}
+	  }
+	}`
+		graphqlVariables := GraphqlVariables{Organization: githubClient.Organization, RepoName: repoName, Cursor: cursor}
+		graphqlRequestBody := GraphqlRequestBody{Variables: graphqlVariables, Query: query}
+		labelUsages := LabelUsages{}
+		githubClient.request(
+			http.MethodPost,
+			"https://api.github.com/graphql",
+			&labelUsages,
+			func(statusCode int, errorBody ErrorResponseBody) bool { return statusCode == 200 },
+			graphqlRequestBody,
+		)
+		labels := labelUsages.Data.Repository.Labels
+		for i := 0; i < len(labels.Nodes); i++ {
+			node := labels.Nodes[i]
+			result = append(result, githubstructures.LabelUsage{
+				Label:     githubstructures.Label{Name: node.Name, Color: node.Color, Description: node.Description},
+				UsesCount: node.Issues.TotalCount + node.PullRequests.TotalCount,
+			})
+		}
+		if !labels.PageInfo.HasNextPage {
+			break
+		}
+		cursor = &labels.PageInfo.EndCursor
+	}
+	return result
+}
@@ -1,9 +1,15 @@
 package githuboperator
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/brainhubeu/issue-overseer/githubstructures"
-	"log"
+	"github.com/brainhubeu/issue-overseer/labelcolor"
+	"github.com/brainhubeu/issue-overseer/logging"
+	"github.com/brainhubeu/issue-overseer/workcalendar"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type GithubClient interface {
@@ -14,12 +20,70 @@ type GithubClient interface {
 	RemoveLabel(issueUrl string, labelName string)
 	AddLabel(issueUrl string, labelName string)
 	RenameLabel(repoName string, oldLabelName string, newLabelName string)
+	UpdateLabel(repoName string, labelName string, label githubstructures.Label)
 	FindIssues(repoName string) []githubstructures.Issue
+	FindIssue(repoName string, number int) (githubstructures.Issue, bool)
+	FindIssuesByLabel(repoName string, labelName string) []githubstructures.Issue
+	FindAllIssues(repoName string) []githubstructures.Issue
+	FindLabelUsages(repoName string) []githubstructures.LabelUsage
+	CreateComment(issueUrl string, body string)
+	CloseIssue(issueUrl string)
 }
 
 type IssuesTriage interface {
+	TriageOneIssueByAnswering(issue githubstructures.Issue) githubstructures.TriageDecision
+	TriageOneIssueByManualLabel(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.TriageDecision
+	TriageOneIssueByStaleness(issue githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) githubstructures.TriageDecision
 	GroupByAnswering(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue)
 	GroupByManualLabel(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue)
+	WaitingSince(issue githubstructures.Issue) time.Time
+	GroupByDeadline(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue)
+	TriageOneIssueByEscalation(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int
+	EscalationMarker(stage int) string
+	GroupByStaleness(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue)
+	StaleMarker() string
+	ResolveExclusiveGroup(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool)
+	FindOrphanedLabels(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string
+	FindSplitLabel(issue githubstructures.Issue, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) string
+}
+
+type RepoConfigs interface {
+	ForRepo(repoName string) githubstructures.RepoConfig
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Auditor interface {
+	Record(entry githubstructures.AuditEntry)
+}
+
+const (
+	labelsRule                = "labels"
+	missingLabelsRule         = "missing labels"
+	answeringRule             = "answering"
+	overdueRule               = "overdue"
+	escalationRule            = "escalation"
+	staleRule                 = "stale"
+	exclusiveGroupsRule       = "exclusive groups"
+	orphanedLabelsRule        = "orphaned labels"
+	renameMigrationRule       = "migration: rename"
+	mergeMigrationRule        = "migration: merge"
+	recolorMigrationRule      = "migration: recolor"
+	deleteUnusedMigrationRule = "migration: delete if unused"
+	splitMigrationRule        = "migration: split"
+	rollbackRule              = "rollback"
+	restoreRule               = "restore"
+	importRule                = "labels import"
+	pruneRule                 = "labels prune"
+)
+
+type LabelMatcher interface {
+	Key(labelName string) string
+	Equal(labelName string, otherLabelName string) bool
+	HasPrefix(labelName string, prefix string) bool
+	Value(labelName string) string
 }
 
 type githuboperator struct {
@@ -31,115 +95,1185 @@ type githuboperator struct {
 	NOT_ANSWERED_LABEL_TEXT string
 	DefaultLabels           []githubstructures.Label
 	manualLabelConfigs      []githubstructures.ManualLabelConfig
+	repoConfigs             RepoConfigs
+	clock                   Clock
+	auditor                 Auditor
+	labelMatcher            LabelMatcher
+	issueNumbers            []int
+	stepRecorder            func(step githubstructures.LabelStep)
 }
 
-func New(githubClient GithubClient, issuesTriage IssuesTriage, answeringLabels []githubstructures.Label, OUR_LABEL_TEXT string, ANSWERED_LABEL_TEXT string, NOT_ANSWERED_LABEL_TEXT string, defaultLabels []githubstructures.Label, manualLabelConfigs []githubstructures.ManualLabelConfig) *githuboperator {
-	githubOperator := &githuboperator{githubClient, issuesTriage, answeringLabels, OUR_LABEL_TEXT, ANSWERED_LABEL_TEXT, NOT_ANSWERED_LABEL_TEXT, defaultLabels, manualLabelConfigs}
+func New(githubClient GithubClient, issuesTriage IssuesTriage, answeringLabels []githubstructures.Label, OUR_LABEL_TEXT string, ANSWERED_LABEL_TEXT string, NOT_ANSWERED_LABEL_TEXT string, defaultLabels []githubstructures.Label, manualLabelConfigs []githubstructures.ManualLabelConfig, repoConfigs RepoConfigs, clock Clock, auditor Auditor, labelMatcher LabelMatcher) *githuboperator {
+	githubOperator := &githuboperator{githubClient, issuesTriage, answeringLabels, OUR_LABEL_TEXT, ANSWERED_LABEL_TEXT, NOT_ANSWERED_LABEL_TEXT, defaultLabels, manualLabelConfigs, repoConfigs, clock, auditor, labelMatcher, []int{}, nil}
 	return githubOperator
 }
 
-func (githubOperator githuboperator) createOrUpdateRepoLabels(repoName string) {
-	allLabels := githubOperator.githubclient.FindLabels(repoName)
-	labelsToDelete := []githubstructures.Label{}
-	for i := 0; i < len(githubOperator.DefaultLabels); i++ {
-		label := githubOperator.DefaultLabels[i]
-		for j := 0; j < len(allLabels); j++ {
-			anyLabel := allLabels[j]
-			if strings.EqualFold(label.Name, anyLabel.Name) && label.Color != anyLabel.Color {
-				labelsToDelete = append(labelsToDelete, label)
+type issuesClient struct {
+	GithubClient
+	issueNumbers []int
+}
+
+func (githubClient issuesClient) filterIssues(issues []githubstructures.Issue) []githubstructures.Issue {
+	filteredIssues := []githubstructures.Issue{}
+	for i := 0; i < len(issues); i++ {
+		for j := 0; j < len(githubClient.issueNumbers); j++ {
+			if issues[i].Number == githubClient.issueNumbers[j] {
+				filteredIssues = append(filteredIssues, issues[i])
+				break
 			}
 		}
 	}
-	labelsToCreate := append([]githubstructures.Label{}, labelsToDelete...)
-	for i := 0; i < len(githubOperator.DefaultLabels); i++ {
-		label := githubOperator.DefaultLabels[i]
-		j := 0
-		for ; j < len(allLabels); j++ {
-			anyLabel := allLabels[j]
-			if strings.EqualFold(label.Name, anyLabel.Name) {
-				break
-			}
+	return filteredIssues
+}
+
+func (githubClient issuesClient) FindIssues(repoName string) []githubstructures.Issue {
+	return githubClient.filterIssues(githubClient.GithubClient.FindIssues(repoName))
+}
+
+func (githubClient issuesClient) FindIssuesByLabel(repoName string, labelName string) []githubstructures.Issue {
+	return githubClient.filterIssues(githubClient.GithubClient.FindIssuesByLabel(repoName, labelName))
+}
+
+func (githubClient issuesClient) FindAllIssues(repoName string) []githubstructures.Issue {
+	return githubClient.filterIssues(githubClient.GithubClient.FindAllIssues(repoName))
+}
+
+func (githubOperator githuboperator) ForIssues(issueNumbers []int) *githuboperator {
+	githubOperator.githubclient = issuesClient{GithubClient: githubOperator.githubclient, issueNumbers: issueNumbers}
+	githubOperator.issueNumbers = issueNumbers
+	return &githubOperator
+}
+
+func (githubOperator githuboperator) isSkippingRepoLabels(repoName string, operation string, labelName string) bool {
+	if len(githubOperator.issueNumbers) == 0 {
+		return false
+	}
+	logging.Info(logging.Fields{Repo: repoName, Operation: operation}, "skipping", labelName, "when only updating issues", githubOperator.issueNumbers)
+	return true
+}
+
+const missingLabelMarker = "(managed by issue-overseer)"
+
+func missingLabelName(config githubstructures.ManualLabelConfig) string {
+	return "missing " + config.Prefix
+}
+
+func (githubOperator githuboperator) findLabel(labels []githubstructures.Label, labelName string) int {
+	for i := 0; i < len(labels); i++ {
+		if labels[i].Name == labelName {
+			return i
+		}
+	}
+	for i := 0; i < len(labels); i++ {
+		if githubOperator.labelMatcher.Equal(labels[i].Name, labelName) {
+			return i
 		}
-		if j == len(allLabels) {
-			labelsToCreate = append(labelsToCreate, label)
+	}
+	return -1
+}
+
+func (githubOperator githuboperator) managedLabels() []githubstructures.Label {
+	labels := append([]githubstructures.Label{}, githubOperator.DefaultLabels...)
+	for i := 0; i < len(githubOperator.manualLabelConfigs); i++ {
+		config := githubOperator.manualLabelConfigs[i]
+		if githubOperator.findLabel(labels, missingLabelName(config)) != -1 {
+			continue
+		}
+		labels = append(labels, githubstructures.Label{
+			Name:        missingLabelName(config),
+			Color:       config.MissingLabelColor,
+			Description: strings.TrimSpace(config.MissingLabelDescription + " " + missingLabelMarker),
+		})
+	}
+	return labels
+}
+
+func issueNumber(issueUrl string) int {
+	number, _ := strconv.Atoi(issueUrl[strings.LastIndex(issueUrl, "/")+1:])
+	return number
+}
+
+func formatLabel(label githubstructures.Label) string {
+	text := label.Name + " #" + label.Color
+	if label.Description != "" {
+		text += " (" + label.Description + ")"
+	}
+	return text
+}
+
+func (githubOperator githuboperator) audit(rule string, repoName string, issueUrl string, operation string, before string, after string) {
+	githubOperator.auditor.Record(githubstructures.AuditEntry{
+		Repo:      repoName,
+		Issue:     issueNumber(issueUrl),
+		Operation: operation,
+		Before:    before,
+		After:     after,
+		Rule:      rule,
+	})
+}
+
+func (githubOperator githuboperator) addIssueLabel(rule string, repoName string, issueUrl string, labelName string) {
+	githubOperator.githubclient.AddLabel(issueUrl, labelName)
+	githubOperator.audit(rule, repoName, issueUrl, "AddLabel", "", labelName)
+}
+
+func (githubOperator githuboperator) removeIssueLabel(rule string, repoName string, issueUrl string, labelName string) {
+	githubOperator.githubclient.RemoveLabel(issueUrl, labelName)
+	githubOperator.audit(rule, repoName, issueUrl, "RemoveLabel", labelName, "")
+}
+
+func findIssue(issues []githubstructures.Issue, issueUrl string) int {
+	for i := 0; i < len(issues); i++ {
+		if issues[i].Url == issueUrl {
+			return i
 		}
 	}
-	log.Println(repoName, "labelsToDelete", labelsToDelete)
-	log.Println(repoName, "labelsToCreate", labelsToCreate)
-	for i := 0; i < len(labelsToDelete); i++ {
-		githubOperator.githubclient.DeleteLabel(repoName, labelsToDelete[i].Name)
+	return -1
+}
+
+func currentLabels(issues []githubstructures.Issue, issue githubstructures.Issue) []githubstructures.Label {
+	i := findIssue(issues, issue.Url)
+	if i == -1 {
+		return issue.Labels
+	}
+	return issues[i].Labels
+}
+
+func setCurrentLabels(issues []githubstructures.Issue, issue githubstructures.Issue, labels []githubstructures.Label) {
+	i := findIssue(issues, issue.Url)
+	if i != -1 {
+		issues[i].Labels = labels
+	}
+}
+
+func (githubOperator githuboperator) labelIssue(rule string, repoName string, issues []githubstructures.Issue, issue githubstructures.Issue, labelName string) {
+	labels := currentLabels(issues, issue)
+	if githubOperator.findLabel(labels, labelName) != -1 {
+		return
+	}
+	githubOperator.addIssueLabel(rule, repoName, issue.Url, labelName)
+	setCurrentLabels(issues, issue, append(append([]githubstructures.Label{}, labels...), githubstructures.Label{Name: labelName}))
+}
+
+func (githubOperator githuboperator) unlabelIssue(rule string, repoName string, issues []githubstructures.Issue, issue githubstructures.Issue, labelName string) bool {
+	labels := currentLabels(issues, issue)
+	i := githubOperator.findLabel(labels, labelName)
+	if i == -1 {
+		return false
+	}
+	githubOperator.removeIssueLabel(rule, repoName, issue.Url, labels[i].Name)
+	setCurrentLabels(issues, issue, append(append([]githubstructures.Label{}, labels[:i]...), labels[i+1:]...))
+	return true
+}
+
+func (githubOperator githuboperator) createRepoLabel(rule string, repoName string, label githubstructures.Label) {
+	if githubOperator.isSkippingRepoLabels(repoName, "CreateLabel", label.Name) {
+		return
+	}
+	githubOperator.githubclient.CreateLabel(repoName, label)
+	githubOperator.audit(rule, repoName, "", "CreateLabel", "", formatLabel(label))
+}
+
+func (githubOperator githuboperator) deleteRepoLabel(rule string, repoName string, label githubstructures.Label) {
+	if githubOperator.isSkippingRepoLabels(repoName, "DeleteLabel", label.Name) {
+		return
+	}
+	githubOperator.githubclient.DeleteLabel(repoName, label.Name)
+	githubOperator.audit(rule, repoName, "", "DeleteLabel", formatLabel(label), "")
+}
+
+func (githubOperator githuboperator) renameRepoLabel(rule string, repoName string, oldLabelName string, newLabelName string) {
+	if githubOperator.isSkippingRepoLabels(repoName, "RenameLabel", oldLabelName) {
+		return
+	}
+	githubOperator.githubclient.RenameLabel(repoName, oldLabelName, newLabelName)
+	githubOperator.audit(rule, repoName, "", "RenameLabel", oldLabelName, newLabelName)
+}
+
+func (githubOperator githuboperator) updateRepoLabel(rule string, repoName string, before githubstructures.Label, after githubstructures.Label) {
+	if githubOperator.isSkippingRepoLabels(repoName, "UpdateLabel", before.Name) {
+		return
+	}
+	githubOperator.githubclient.UpdateLabel(repoName, before.Name, after)
+	githubOperator.audit(rule, repoName, "", "UpdateLabel", formatLabel(before), formatLabel(after))
+}
+
+func (githubOperator githuboperator) createComment(rule string, repoName string, issueUrl string, body string) {
+	githubOperator.githubclient.CreateComment(issueUrl, body)
+	githubOperator.audit(rule, repoName, issueUrl, "CreateComment", "", body)
+}
+
+func (githubOperator githuboperator) closeIssue(rule string, repoName string, issueUrl string) {
+	githubOperator.githubclient.CloseIssue(issueUrl)
+	githubOperator.audit(rule, repoName, issueUrl, "CloseIssue", "open", "closed")
+}
+
+func (githubOperator githuboperator) deleteObsoleteMissingLabels(repoName string, allLabels []githubstructures.Label, managedLabels []githubstructures.Label) {
+	obsoleteLabels := []githubstructures.Label{}
+	for i := 0; i < len(allLabels); i++ {
+		label := allLabels[i]
+		isOwned := strings.HasPrefix(githubOperator.labelMatcher.Key(label.Name), "missing ") && strings.HasSuffix(label.Description, missingLabelMarker)
+		if isOwned && githubOperator.findLabel(managedLabels, label.Name) == -1 {
+			obsoleteLabels = append(obsoleteLabels, label)
+		}
 	}
-	for i := 0; i < len(labelsToCreate); i++ {
-		githubOperator.githubclient.CreateLabel(repoName, labelsToCreate[i])
+	logging.Debug(logging.Fields{Repo: repoName, Rule: missingLabelsRule}, "obsolete missing labels", obsoleteLabels)
+	for i := 0; i < len(obsoleteLabels); i++ {
+		githubOperator.deleteRepoLabel(missingLabelsRule, repoName, obsoleteLabels[i])
 	}
 }
 
-func (githubOperator githuboperator) updateIssueLabels(issueUrl string, allIssueLabels []githubstructures.Label, labelNameToAdd string) {
+func (githubOperator githuboperator) createOrUpdateRepoLabels(repoName string) {
+	managedLabels := githubOperator.managedLabels()
+	allLabels := githubOperator.githubclient.FindLabels(repoName)
+	steps := githubOperator.applyLabels(labelsRule, repoName, allLabels, managedLabels, false)
+	logging.Info(logging.Fields{Repo: repoName, Rule: labelsRule}, "label changes", len(steps))
+	githubOperator.deleteObsoleteMissingLabels(repoName, allLabels, managedLabels)
+}
+
+func (githubOperator githuboperator) updateIssueLabels(repoName string, issues []githubstructures.Issue, issue githubstructures.Issue, labelNameToAdd string) {
+	allIssueLabels := issue.Labels
 	labelsToRemove := []githubstructures.Label{}
 	for i := 0; i < len(allIssueLabels); i++ {
 		j := 0
 		for ; j < len(githubOperator.AnsweringLabels); j++ {
-			if githubOperator.AnsweringLabels[j].Name == allIssueLabels[i].Name {
+			if githubOperator.labelMatcher.Equal(githubOperator.AnsweringLabels[j].Name, allIssueLabels[i].Name) {
 				break
 			}
 		}
-		if j < len(githubOperator.AnsweringLabels) && allIssueLabels[i].Name != labelNameToAdd {
+		if j < len(githubOperator.AnsweringLabels) && !githubOperator.labelMatcher.Equal(allIssueLabels[i].Name, labelNameToAdd) {
 			labelsToRemove = append(labelsToRemove, allIssueLabels[i])
 		}
 	}
-	log.Println(issueUrl, "labelsToRemove", labelsToRemove)
+	logging.Debug(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: answeringRule}, "labels to remove", labelsToRemove)
 	for i := 0; i < len(labelsToRemove); i++ {
-		githubOperator.githubclient.RemoveLabel(issueUrl, labelsToRemove[i].Name)
+		githubOperator.unlabelIssue(answeringRule, repoName, issues, issue, labelsToRemove[i].Name)
+	}
+	githubOperator.labelIssue(answeringRule, repoName, issues, issue, labelNameToAdd)
+}
+
+func (githubOperator githuboperator) waitingTime(repoName string, issue githubstructures.Issue) time.Duration {
+	waitingSince := githubOperator.issuestriage.WaitingSince(issue)
+	if waitingSince.IsZero() {
+		return 0
 	}
-	githubOperator.githubclient.AddLabel(issueUrl, labelNameToAdd)
+	calendar := workcalendar.New(githubOperator.repoConfigs.ForRepo(repoName).Calendar)
+	return calendar.BusinessDuration(waitingSince, githubOperator.clock.Now())
 }
 
-func (githubOperator githuboperator) updateAnsweringLabelsForRepo(repoName string) {
-	issues := githubOperator.githubclient.FindIssues(repoName)
+func (githubOperator githuboperator) updateAnsweringLabelsForRepo(repoName string, issues []githubstructures.Issue) {
 	ourIssues, answeredIssues, notAnsweredIssues := githubOperator.issuestriage.GroupByAnswering(issues)
-	log.Println(repoName, "ourIssues", ourIssues)
-	log.Println(repoName, "answeredIssues", answeredIssues)
-	log.Println(repoName, "notAnsweredIssues", notAnsweredIssues)
+	fields := logging.Fields{Repo: repoName, Rule: answeringRule}
+	logging.Info(fields, len(ourIssues), "ours,", len(answeredIssues), "answered,", len(notAnsweredIssues), "not answered")
+	logging.Debug(fields, "our issues", ourIssues)
+	logging.Debug(fields, "answered issues", answeredIssues)
+	logging.Debug(fields, "not answered issues", notAnsweredIssues)
 	for i := 0; i < len(ourIssues); i++ {
-		githubOperator.updateIssueLabels(ourIssues[i].Url, ourIssues[i].Labels, githubOperator.OUR_LABEL_TEXT)
+		githubOperator.updateIssueLabels(repoName, issues, ourIssues[i], githubOperator.OUR_LABEL_TEXT)
 	}
 	for i := 0; i < len(answeredIssues); i++ {
-		githubOperator.updateIssueLabels(answeredIssues[i].Url, answeredIssues[i].Labels, githubOperator.ANSWERED_LABEL_TEXT)
+		githubOperator.updateIssueLabels(repoName, issues, answeredIssues[i], githubOperator.ANSWERED_LABEL_TEXT)
 	}
 	for i := 0; i < len(notAnsweredIssues); i++ {
-		githubOperator.updateIssueLabels(notAnsweredIssues[i].Url, notAnsweredIssues[i].Labels, githubOperator.NOT_ANSWERED_LABEL_TEXT)
+		logging.Debug(logging.Fields{Repo: repoName, Issue: notAnsweredIssues[i].Number, Rule: answeringRule}, "waiting for an answer for", githubOperator.waitingTime(repoName, notAnsweredIssues[i]))
+		githubOperator.updateIssueLabels(repoName, issues, notAnsweredIssues[i], githubOperator.NOT_ANSWERED_LABEL_TEXT)
 	}
 }
 
-func (githubOperator githuboperator) updateMissingManualLabelsForRepo(repoName string) {
+func (githubOperator githuboperator) updateMissingManualLabelsForRepo(repoName string, issues []githubstructures.Issue) {
 	configs := githubOperator.manualLabelConfigs
 	for i := 0; i < len(configs); i++ {
 		config := configs[i]
-		issues := githubOperator.githubclient.FindIssues(repoName)
 		issuesWithLabel, issuesWithoutLabel := githubOperator.issuestriage.GroupByManualLabel(issues, config)
-		log.Println(repoName, "issues with manual label", config.Prefix, issuesWithLabel)
-		log.Println(repoName, "issues without manual label", config.Prefix, issuesWithoutLabel)
+		fields := logging.Fields{Repo: repoName, Rule: missingLabelsRule}
+		logging.Info(fields, len(issuesWithoutLabel), "issues without a", config.Prefix, "label")
+		logging.Debug(fields, "issues with manual label", config.Prefix, issuesWithLabel)
+		logging.Debug(fields, "issues without manual label", config.Prefix, issuesWithoutLabel)
 		for j := 0; j < len(issuesWithLabel); j++ {
-			githubOperator.githubclient.RemoveLabel(issuesWithLabel[j].Url, "missing "+config.Prefix)
+			githubOperator.unlabelIssue(missingLabelsRule, repoName, issues, issuesWithLabel[j], missingLabelName(config))
 		}
 		for j := 0; j < len(issuesWithoutLabel); j++ {
-			githubOperator.githubclient.AddLabel(issuesWithoutLabel[j].Url, "missing "+config.Prefix)
+			githubOperator.labelIssue(missingLabelsRule, repoName, issues, issuesWithoutLabel[j], missingLabelName(config))
+		}
+	}
+}
+
+func (githubOperator githuboperator) hasLabel(labels []githubstructures.Label, labelName string) bool {
+	for i := 0; i < len(labels); i++ {
+		if githubOperator.labelMatcher.Equal(labels[i].Name, labelName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (githubOperator githuboperator) updateOverdueLabelsForRepo(repoName string, issues []githubstructures.Issue) {
+	repoConfig := githubOperator.repoConfigs.ForRepo(repoName)
+	calendar := workcalendar.New(repoConfig.Calendar)
+	breaches, onTimeIssues := githubOperator.issuestriage.GroupByDeadline(issues, repoConfig.SeverityDeadlines, calendar, githubOperator.clock.Now())
+	logging.Info(logging.Fields{Repo: repoName, Rule: overdueRule}, "overdue issues", len(breaches))
+	for i := 0; i < len(breaches); i++ {
+		breach := breaches[i]
+		logging.Debug(logging.Fields{Repo: repoName, Issue: breach.Issue.Number, Rule: overdueRule}, "overdue", breach.Severity, breach.Kind, "deadline", breach.Deadline, "missed by", breach.MissedBy)
+		githubOperator.labelIssue(overdueRule, repoName, issues, breach.Issue, repoConfig.OverdueLabelName)
+	}
+	for i := 0; i < len(onTimeIssues); i++ {
+		githubOperator.unlabelIssue(overdueRule, repoName, issues, onTimeIssues[i], repoConfig.OverdueLabelName)
+	}
+}
+
+func formatAge(duration time.Duration) string {
+	hours := int(duration.Hours())
+	if hours >= 24 {
+		return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+	}
+	return fmt.Sprintf("%dh", hours)
+}
+
+func (githubOperator githuboperator) updateEscalationsForRepo(repoName string, issues []githubstructures.Issue) {
+	escalation := githubOperator.repoConfigs.ForRepo(repoName).Escalation
+	if len(escalation.Stages) == 0 || escalation.Mention == "" {
+		return
+	}
+	now := githubOperator.clock.Now()
+	_, _, notAnsweredIssues := githubOperator.issuestriage.GroupByAnswering(issues)
+	for i := 0; i < len(notAnsweredIssues); i++ {
+		issue := notAnsweredIssues[i]
+		waitingTime := githubOperator.waitingTime(repoName, issue)
+		stage := githubOperator.issuestriage.TriageOneIssueByEscalation(issue, escalation.Stages, waitingTime)
+		if stage == 0 {
+			continue
+		}
+		data := githubstructures.EscalationTemplateData{
+			Mention:      escalation.Mention,
+			Reporter:     issue.AuthorLogin,
+			Title:        issue.Title,
+			Url:          issue.Url,
+			Age:          formatAge(now.Sub(issue.CreatedAt)),
+			WaitingHours: int(waitingTime.Hours()),
+			Stage:        stage,
+		}
+		body := bytes.Buffer{}
+		err := escalation.Template.Execute(&body, data)
+		if err != nil {
+			logging.Warn(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: escalationRule}, "invalid escalation template", err)
+			continue
+		}
+		body.WriteString("\n\n" + githubOperator.issuestriage.EscalationMarker(stage))
+		logging.Info(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: escalationRule}, "escalation stage", stage)
+		githubOperator.createComment(escalationRule, repoName, issue.Url, body.String())
+	}
+}
+
+func (githubOperator githuboperator) updateStaleIssuesForRepo(repoName string, issues []githubstructures.Issue) {
+	stale := githubOperator.repoConfigs.ForRepo(repoName).Stale
+	if stale.After == 0 {
+		return
+	}
+	becomingStaleIssues, revivedIssues, issuesToClose := githubOperator.issuestriage.GroupByStaleness(issues, stale, githubOperator.clock.Now())
+	fields := logging.Fields{Repo: repoName, Rule: staleRule}
+	logging.Info(fields, len(becomingStaleIssues), "becoming stale,", len(revivedIssues), "revived,", len(issuesToClose), "to close")
+	logging.Debug(fields, "becoming stale issues", becomingStaleIssues)
+	logging.Debug(fields, "revived issues", revivedIssues)
+	logging.Debug(fields, "issues to close", issuesToClose)
+	for i := 0; i < len(becomingStaleIssues); i++ {
+		issue := becomingStaleIssues[i]
+		data := githubstructures.StaleTemplateData{
+			Reporter:       issue.AuthorLogin,
+			Days:           int(stale.After.Hours() / 24),
+			CloseAfterDays: int(stale.CloseAfter.Hours() / 24),
+		}
+		body := bytes.Buffer{}
+		err := stale.Warning.Execute(&body, data)
+		if err != nil {
+			logging.Warn(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: staleRule}, "invalid stale warning template", err)
+			continue
+		}
+		body.WriteString("\n\n" + githubOperator.issuestriage.StaleMarker())
+		githubOperator.labelIssue(staleRule, repoName, issues, issue, stale.LabelName)
+		githubOperator.createComment(staleRule, repoName, issue.Url, body.String())
+	}
+	for i := 0; i < len(revivedIssues); i++ {
+		githubOperator.unlabelIssue(staleRule, repoName, issues, revivedIssues[i], stale.LabelName)
+	}
+	for i := 0; i < len(issuesToClose); i++ {
+		githubOperator.closeIssue(staleRule, repoName, issuesToClose[i].Url)
+	}
+}
+
+func (githubOperator githuboperator) updateExclusiveLabelsForRepo(repoName string, issues []githubstructures.Issue) {
+	repoConfig := githubOperator.repoConfigs.ForRepo(repoName)
+	if len(repoConfig.ExclusiveGroups) == 0 {
+		return
+	}
+	for i := 0; i < len(issues); i++ {
+		issue := issues[i]
+		isConflict := false
+		for j := 0; j < len(repoConfig.ExclusiveGroups); j++ {
+			labelNamesToRemove, isGroupConflict := githubOperator.issuestriage.ResolveExclusiveGroup(issue, repoConfig.ExclusiveGroups[j])
+			if len(labelNamesToRemove) > 0 {
+				logging.Info(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: exclusiveGroupsRule}, "exclusive labels to remove", labelNamesToRemove)
+			}
+			for k := 0; k < len(labelNamesToRemove); k++ {
+				githubOperator.unlabelIssue(exclusiveGroupsRule, repoName, issues, issue, labelNamesToRemove[k])
+			}
+			isConflict = isConflict || isGroupConflict
+		}
+		isFlagged := githubOperator.hasLabel(issue.Labels, repoConfig.ConflictLabelName)
+		if isConflict && !isFlagged {
+			logging.Info(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: exclusiveGroupsRule}, "label conflict")
+			githubOperator.labelIssue(exclusiveGroupsRule, repoName, issues, issue, repoConfig.ConflictLabelName)
+		}
+		if !isConflict && isFlagged {
+			githubOperator.unlabelIssue(exclusiveGroupsRule, repoName, issues, issue, repoConfig.ConflictLabelName)
 		}
 	}
 }
 
-func (githubOperator githuboperator) UpdateRepos(repoNames []string) {
+func (githubOperator githuboperator) updateOrphanedLabelsForRepo(repoName string, issues []githubstructures.Issue) []githubstructures.LabelChange {
+	removals := []githubstructures.LabelChange{}
+	configs := []githubstructures.ManualLabelConfig{}
+	for i := 0; i < len(githubOperator.manualLabelConfigs); i++ {
+		config := githubOperator.manualLabelConfigs[i]
+		if config.DependencyMode == githubstructures.ManualLabelDependencyModeEnum.REMOVE || config.DependencyMode == githubstructures.ManualLabelDependencyModeEnum.FLAG {
+			configs = append(configs, config)
+		}
+	}
+	if len(configs) == 0 {
+		return removals
+	}
+	invalidCombinationLabelName := githubOperator.repoConfigs.ForRepo(repoName).InvalidCombinationLabelName
+	for i := 0; i < len(issues); i++ {
+		issue := issues[i]
+		isInvalid := false
+		for j := 0; j < len(configs); j++ {
+			orphanedLabelNames := githubOperator.issuestriage.FindOrphanedLabels(issue, configs[j])
+			if len(orphanedLabelNames) == 0 {
+				continue
+			}
+			logging.Info(logging.Fields{Repo: repoName, Issue: issue.Number, Rule: orphanedLabelsRule}, "orphaned labels", configs[j].Prefix, orphanedLabelNames)
+			if configs[j].DependencyMode == githubstructures.ManualLabelDependencyModeEnum.FLAG {
+				isInvalid = true
+				continue
+			}
+			for k := 0; k < len(orphanedLabelNames); k++ {
+				if githubOperator.unlabelIssue(orphanedLabelsRule, repoName, issues, issue, orphanedLabelNames[k]) {
+					removals = append(removals, githubstructures.LabelChange{IssueUrl: issue.Url, LabelName: orphanedLabelNames[k]})
+				}
+			}
+		}
+		isFlagged := githubOperator.hasLabel(issue.Labels, invalidCombinationLabelName)
+		if isInvalid && !isFlagged {
+			githubOperator.labelIssue(orphanedLabelsRule, repoName, issues, issue, invalidCombinationLabelName)
+		}
+		if !isInvalid && isFlagged {
+			githubOperator.unlabelIssue(orphanedLabelsRule, repoName, issues, issue, invalidCombinationLabelName)
+		}
+	}
+	return removals
+}
+
+type countingAuditor struct {
+	Auditor
+	counts map[string]int
+}
+
+func (auditor countingAuditor) Record(entry githubstructures.AuditEntry) {
+	auditor.counts[entry.Operation]++
+	auditor.Auditor.Record(entry)
+}
+
+func (githubOperator githuboperator) UpdateRepos(repoNames []string) githubstructures.RunSummary {
+	summary := githubstructures.RunSummary{Repos: []githubstructures.RepoSummary{}}
+	auditor := githubOperator.auditor
 	for i := 0; i < len(repoNames); i++ {
 		repoName := repoNames[i]
+		counts := map[string]int{}
+		githubOperator.auditor = countingAuditor{Auditor: auditor, counts: counts}
 		githubOperator.createOrUpdateRepoLabels(repoName)
-		githubOperator.updateAnsweringLabelsForRepo(repoName)
-		githubOperator.updateMissingManualLabelsForRepo(repoName)
+		issues := githubOperator.githubclient.FindIssues(repoName)
+		githubOperator.updateExclusiveLabelsForRepo(repoName, issues)
+		orphanedLabelRemovals := githubOperator.updateOrphanedLabelsForRepo(repoName, issues)
+		githubOperator.updateAnsweringLabelsForRepo(repoName, issues)
+		githubOperator.updateMissingManualLabelsForRepo(repoName, issues)
+		githubOperator.updateOverdueLabelsForRepo(repoName, issues)
+		githubOperator.updateEscalationsForRepo(repoName, issues)
+		githubOperator.updateStaleIssuesForRepo(repoName, issues)
+		summary.Repos = append(summary.Repos, githubstructures.RepoSummary{
+			RepoName:               repoName,
+			OrphanedLabelRemovals:  orphanedLabelRemovals,
+			Report:                 githubOperator.reportRepo(repoName, issues),
+			LabelsCreatedCount:     counts["CreateLabel"],
+			LabelsUpdatedCount:     counts["UpdateLabel"] + counts["RenameLabel"],
+			LabelsDeletedCount:     counts["DeleteLabel"],
+			IssueLabelChangesCount: counts["AddLabel"] + counts["RemoveLabel"],
+		})
 	}
+	return summary
 }
 
-func (githubOperator githuboperator) RenameLabelInEachRepo(repoNames []string, oldLabelName string, newLabelName string) {
+func (githubOperator githuboperator) reportRepo(repoName string, issues []githubstructures.Issue) githubstructures.RepoReport {
+	ourIssues, answeredIssues, notAnsweredIssues := githubOperator.issuestriage.GroupByAnswering(issues)
+	missingLabelCounts := []githubstructures.MissingLabelCount{}
+	for i := 0; i < len(githubOperator.manualLabelConfigs); i++ {
+		config := githubOperator.manualLabelConfigs[i]
+		_, issuesWithoutLabel := githubOperator.issuestriage.GroupByManualLabel(issues, config)
+		missingLabelCounts = append(missingLabelCounts, githubstructures.MissingLabelCount{Prefix: config.Prefix, Count: len(issuesWithoutLabel)})
+	}
+	return githubstructures.RepoReport{
+		RepoName:           repoName,
+		OpenIssuesCount:    len(issues),
+		OursCount:          len(ourIssues),
+		AnsweredCount:      len(answeredIssues),
+		NotAnsweredCount:   len(notAnsweredIssues),
+		MissingLabelCounts: missingLabelCounts,
+	}
+}
+
+func (githubOperator githuboperator) ReportRepos(repoNames []string) []githubstructures.RepoReport {
+	reports := []githubstructures.RepoReport{}
+	for i := 0; i < len(repoNames); i++ {
+		reports = append(reports, githubOperator.reportRepo(repoNames[i], githubOperator.githubclient.FindIssues(repoNames[i])))
+	}
+	return reports
+}
+
+type dryRunClient struct {
+	GithubClient
+}
+
+func (githubClient dryRunClient) DeleteLabel(repoName string, labelName string) {
+}
+
+func (githubClient dryRunClient) CreateLabel(repoName string, label githubstructures.Label) {
+}
+
+func (githubClient dryRunClient) RemoveLabel(issueUrl string, labelName string) {
+}
+
+func (githubClient dryRunClient) AddLabel(issueUrl string, labelName string) {
+}
+
+func (githubClient dryRunClient) RenameLabel(repoName string, oldLabelName string, newLabelName string) {
+}
+
+func (githubClient dryRunClient) UpdateLabel(repoName string, labelName string, label githubstructures.Label) {
+}
+
+func (githubClient dryRunClient) CreateComment(issueUrl string, body string) {
+}
+
+func (githubClient dryRunClient) CloseIssue(issueUrl string) {
+}
+
+type dryRunAuditor struct{}
+
+func (auditor dryRunAuditor) Record(entry githubstructures.AuditEntry) {
+	logging.Info(logging.Fields{Repo: entry.Repo, Issue: entry.Issue, Rule: entry.Rule, Operation: entry.Operation}, "dry run:", strconv.Quote(entry.Before), "->", strconv.Quote(entry.After))
+}
+
+func (githubOperator githuboperator) DryRun() *githuboperator {
+	githubOperator.githubclient = dryRunClient{githubOperator.githubclient}
+	githubOperator.auditor = dryRunAuditor{}
+	return &githubOperator
+}
+
+type plannedChanges struct {
+	entries []githubstructures.AuditEntry
+}
+
+func (changes *plannedChanges) Record(entry githubstructures.AuditEntry) {
+	changes.entries = append(changes.entries, entry)
+}
+
+func (githubOperator githuboperator) explainExclusiveGroup(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) githubstructures.RuleDecision {
+	groupName := group.Prefix
+	if groupName == "" {
+		groupName = strings.Join(group.LabelNames, ", ")
+	}
+	decision := githubstructures.TriageDecision{Type: githubstructures.IssueExclusiveGroupTypeEnum.CONSISTENT, Reason: "at most one label of the group"}
+	labelNamesToRemove, isConflict := githubOperator.issuestriage.ResolveExclusiveGroup(issue, group)
+	if isConflict {
+		decision = githubstructures.TriageDecision{Type: githubstructures.IssueExclusiveGroupTypeEnum.CONFLICT, Reason: "several labels of the group, flagged as a conflict"}
+	} else if len(labelNamesToRemove) > 0 {
+		decision = githubstructures.TriageDecision{Type: githubstructures.IssueExclusiveGroupTypeEnum.RESOLVED, Reason: "several labels of the group, removing " + strings.Join(labelNamesToRemove, ", ")}
+	}
+	return githubstructures.RuleDecision{Rule: exclusiveGroupsRule + ": " + groupName, Decision: decision}
+}
+
+func (githubOperator githuboperator) explainOrphanedLabels(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.RuleDecision {
+	decision := githubstructures.TriageDecision{Type: githubstructures.IssueOrphanedLabelsTypeEnum.VALID, Reason: "no " + config.Prefix + " label without the " + config.ParentLabelName + " label"}
+	orphanedLabelNames := githubOperator.issuestriage.FindOrphanedLabels(issue, config)
+	if len(orphanedLabelNames) > 0 && config.DependencyMode == githubstructures.ManualLabelDependencyModeEnum.FLAG {
+		decision = githubstructures.TriageDecision{Type: githubstructures.IssueOrphanedLabelsTypeEnum.FLAGGED, Reason: strings.Join(orphanedLabelNames, ", ") + " without the " + config.ParentLabelName + " label, flagged as invalid"}
+	} else if len(orphanedLabelNames) > 0 {
+		decision = githubstructures.TriageDecision{Type: githubstructures.IssueOrphanedLabelsTypeEnum.REMOVED, Reason: strings.Join(orphanedLabelNames, ", ") + " without the " + config.ParentLabelName + " label, removing them"}
+	}
+	return githubstructures.RuleDecision{Rule: orphanedLabelsRule + ": " + config.Prefix, Decision: decision}
+}
+
+func (githubOperator githuboperator) explainDeadline(issue githubstructures.Issue, repoConfig githubstructures.RepoConfig) githubstructures.RuleDecision {
+	calendar := workcalendar.New(repoConfig.Calendar)
+	breaches, _ := githubOperator.issuestriage.GroupByDeadline([]githubstructures.Issue{issue}, repoConfig.SeverityDeadlines, calendar, githubOperator.clock.Now())
+	decision := githubstructures.TriageDecision{Type: githubstructures.IssueDeadlineTypeEnum.ON_TIME, Reason: "no deadline missed"}
+	if len(breaches) > 0 {
+		breach := breaches[0]
+		decision = githubstructures.TriageDecision{Type: githubstructures.IssueDeadlineTypeEnum.OVERDUE, Reason: "missed the " + breach.Severity + " " + breach.Kind + " deadline by " + breach.MissedBy.String()}
+	}
+	return githubstructures.RuleDecision{Rule: overdueRule, Decision: decision}
+}
+
+func (githubOperator githuboperator) explainEscalation(repoName string, issue githubstructures.Issue, escalation githubstructures.EscalationConfig) githubstructures.RuleDecision {
+	decision := githubstructures.TriageDecision{Type: githubstructures.IssueEscalationTypeEnum.NOT_ESCALATED, Reason: "only issues waiting for an answer are escalated"}
+	if githubOperator.issuestriage.TriageOneIssueByAnswering(issue).Type == githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED {
+		waitingTime := githubOperator.waitingTime(repoName, issue)
+		stage := githubOperator.issuestriage.TriageOneIssueByEscalation(issue, escalation.Stages, waitingTime)
+		decision.Reason = "no escalation due after waiting " + waitingTime.String()
+		if stage > 0 {
+			decision = githubstructures.TriageDecision{Type: githubstructures.IssueEscalationTypeEnum.ESCALATED, Reason: "escalation stage " + strconv.Itoa(stage) + " after waiting " + waitingTime.String()}
+		}
+	}
+	return githubstructures.RuleDecision{Rule: escalationRule, Decision: decision}
+}
+
+func (githubOperator githuboperator) ExplainIssue(repoName string, number int) (githubstructures.IssueExplanation, bool) {
+	issue, ok := githubOperator.githubclient.FindIssue(repoName, number)
+	if !ok {
+		return githubstructures.IssueExplanation{}, false
+	}
+	decisions := []githubstructures.RuleDecision{
+		githubstructures.RuleDecision{Rule: answeringRule, Decision: githubOperator.issuestriage.TriageOneIssueByAnswering(issue)},
+	}
+	for i := 0; i < len(githubOperator.manualLabelConfigs); i++ {
+		config := githubOperator.manualLabelConfigs[i]
+		decisions = append(decisions, githubstructures.RuleDecision{Rule: missingLabelsRule + ": " + config.Prefix, Decision: githubOperator.issuestriage.TriageOneIssueByManualLabel(issue, config)})
+	}
+	repoConfig := githubOperator.repoConfigs.ForRepo(repoName)
+	for i := 0; i < len(repoConfig.ExclusiveGroups); i++ {
+		decisions = append(decisions, githubOperator.explainExclusiveGroup(issue, repoConfig.ExclusiveGroups[i]))
+	}
+	for i := 0; i < len(githubOperator.manualLabelConfigs); i++ {
+		config := githubOperator.manualLabelConfigs[i]
+		if config.DependencyMode == githubstructures.ManualLabelDependencyModeEnum.REMOVE || config.DependencyMode == githubstructures.ManualLabelDependencyModeEnum.FLAG {
+			decisions = append(decisions, githubOperator.explainOrphanedLabels(issue, config))
+		}
+	}
+	if len(repoConfig.SeverityDeadlines) > 0 {
+		decisions = append(decisions, githubOperator.explainDeadline(issue, repoConfig))
+	}
+	if len(repoConfig.Escalation.Stages) > 0 && repoConfig.Escalation.Mention != "" {
+		decisions = append(decisions, githubOperator.explainEscalation(repoName, issue, repoConfig.Escalation))
+	}
+	if repoConfig.Stale.After > 0 {
+		decisions = append(decisions, githubstructures.RuleDecision{Rule: staleRule, Decision: githubOperator.issuestriage.TriageOneIssueByStaleness(issue, repoConfig.Stale, githubOperator.clock.Now())})
+	}
+	changes := &plannedChanges{entries: []githubstructures.AuditEntry{}}
+	planner := githubOperator
+	planner.githubclient = dryRunClient{githubOperator.githubclient}
+	planner.auditor = changes
+	issues := []githubstructures.Issue{issue}
+	planner.updateExclusiveLabelsForRepo(repoName, issues)
+	planner.updateOrphanedLabelsForRepo(repoName, issues)
+	planner.updateAnsweringLabelsForRepo(repoName, issues)
+	planner.updateMissingManualLabelsForRepo(repoName, issues)
+	planner.updateOverdueLabelsForRepo(repoName, issues)
+	planner.updateEscalationsForRepo(repoName, issues)
+	planner.updateStaleIssuesForRepo(repoName, issues)
+	return githubstructures.IssueExplanation{RepoName: repoName, Issue: issue, Decisions: decisions, PlannedChanges: changes.entries}, true
+}
+
+func (githubOperator *githuboperator) RecordSteps(stepRecorder func(step githubstructures.LabelStep)) {
+	githubOperator.stepRecorder = stepRecorder
+}
+
+func (githubOperator githuboperator) appliedStep(step githubstructures.LabelStep) githubstructures.LabelStep {
+	if githubOperator.stepRecorder != nil {
+		githubOperator.stepRecorder(step)
+	}
+	return step
+}
+
+func (githubOperator githuboperator) relabelIssue(rule string, repoName string, issue githubstructures.Issue, sourceLabelName string, targetLabelName string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	j := githubOperator.findLabel(issue.Labels, targetLabelName)
+	if j == -1 || issue.Labels[j].Name == sourceLabelName {
+		githubOperator.addIssueLabel(rule, repoName, issue.Url, targetLabelName)
+		steps = append(steps, githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: repoName, IssueUrl: issue.Url, LabelName: targetLabelName}))
+	}
+	githubOperator.removeIssueLabel(rule, repoName, issue.Url, sourceLabelName)
+	return append(steps, githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: repoName, IssueUrl: issue.Url, LabelName: sourceLabelName}))
+}
+
+func (githubOperator githuboperator) deleteLabel(rule string, repoName string, label githubstructures.Label) githubstructures.LabelStep {
+	githubOperator.deleteRepoLabel(rule, repoName, label)
+	return githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: repoName, LabelName: label.Name, Before: label})
+}
+
+func (githubOperator githuboperator) renameLabel(rule string, repoName string, oldLabelName string, newLabelName string) githubstructures.LabelStep {
+	githubOperator.renameRepoLabel(rule, repoName, oldLabelName, newLabelName)
+	return githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: repoName, LabelName: oldLabelName, NewLabelName: newLabelName})
+}
+
+func (githubOperator githuboperator) mergeLabel(rule string, repoName string, sourceLabel githubstructures.Label, targetLabelName string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	issues := githubOperator.githubclient.FindIssuesByLabel(repoName, sourceLabel.Name)
+	logging.Info(logging.Fields{Repo: repoName, Rule: rule}, "merging", sourceLabel.Name, "into", targetLabelName, "on", len(issues), "issues and pull requests")
+	for i := 0; i < len(issues); i++ {
+		steps = append(steps, githubOperator.relabelIssue(rule, repoName, issues[i], sourceLabel.Name, targetLabelName)...)
+	}
+	return append(steps, githubOperator.deleteLabel(rule, repoName, sourceLabel))
+}
+
+func (githubOperator githuboperator) RenameLabelInEachRepo(repoNames []string, oldLabelName string, newLabelName string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(repoNames); i++ {
+		repoName := repoNames[i]
+		labels := githubOperator.githubclient.FindLabels(repoName)
+		j := githubOperator.findLabel(labels, oldLabelName)
+		if j == -1 {
+			logging.Info(logging.Fields{Repo: repoName, Rule: renameMigrationRule}, "no label to rename", oldLabelName)
+			continue
+		}
+		k := githubOperator.findLabel(labels, newLabelName)
+		if k != -1 && k != j {
+			steps = append(steps, githubOperator.mergeLabel(renameMigrationRule, repoName, labels[j], newLabelName)...)
+			continue
+		}
+		steps = append(steps, githubOperator.renameLabel(renameMigrationRule, repoName, labels[j].Name, newLabelName))
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) mergeLabelInRepo(rule string, repoName string, sourceLabelName string, targetLabelName string) []githubstructures.LabelStep {
+	labels := githubOperator.githubclient.FindLabels(repoName)
+	j := githubOperator.findLabel(labels, sourceLabelName)
+	if j == -1 {
+		logging.Info(logging.Fields{Repo: repoName, Rule: rule}, "no label to merge", sourceLabelName)
+		return []githubstructures.LabelStep{}
+	}
+	k := githubOperator.findLabel(labels, targetLabelName)
+	if k == -1 || k == j {
+		return []githubstructures.LabelStep{githubOperator.renameLabel(rule, repoName, labels[j].Name, targetLabelName)}
+	}
+	return githubOperator.mergeLabel(rule, repoName, labels[j], targetLabelName)
+}
+
+func (githubOperator githuboperator) MergeLabelInEachRepo(repoNames []string, sourceLabelName string, targetLabelName string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(repoNames); i++ {
+		steps = append(steps, githubOperator.mergeLabelInRepo(mergeMigrationRule, repoNames[i], sourceLabelName, targetLabelName)...)
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) RecolorLabelInEachRepo(repoNames []string, labelName string, color string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
 	for i := 0; i < len(repoNames); i++ {
 		repoName := repoNames[i]
-		githubOperator.githubclient.RenameLabel(repoName, oldLabelName, newLabelName)
+		labels := githubOperator.githubclient.FindLabels(repoName)
+		j := githubOperator.findLabel(labels, labelName)
+		if j == -1 {
+			logging.Info(logging.Fields{Repo: repoName, Rule: recolorMigrationRule}, "no label to recolor", labelName)
+			continue
+		}
+		if labelcolor.Same(labels[j].Color, color) {
+			continue
+		}
+		label := labels[j]
+		label.Color = color
+		githubOperator.updateRepoLabel(recolorMigrationRule, repoName, labels[j], label)
+		steps = append(steps, githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.UPDATE_LABEL, RepoName: repoName, LabelName: labels[j].Name, Before: labels[j], After: label}))
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) deleteUnusedLabelInRepo(rule string, repoName string, labelName string) []githubstructures.LabelStep {
+	labels := githubOperator.githubclient.FindLabels(repoName)
+	j := githubOperator.findLabel(labels, labelName)
+	if j == -1 {
+		return []githubstructures.LabelStep{}
+	}
+	issues := githubOperator.githubclient.FindIssuesByLabel(repoName, labelName)
+	if len(issues) > 0 {
+		logging.Info(logging.Fields{Repo: repoName, Rule: rule}, "label", labelName, "is still used by", len(issues), "issues")
+		return []githubstructures.LabelStep{}
+	}
+	return []githubstructures.LabelStep{githubOperator.deleteLabel(rule, repoName, labels[j])}
+}
+
+func (githubOperator githuboperator) DeleteUnusedLabelInEachRepo(repoNames []string, labelName string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(repoNames); i++ {
+		steps = append(steps, githubOperator.deleteUnusedLabelInRepo(deleteUnusedMigrationRule, repoNames[i], labelName)...)
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) SplitLabelInEachRepo(repoNames []string, labelName string, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(repoNames); i++ {
+		repoName := repoNames[i]
+		labels := githubOperator.githubclient.FindLabels(repoName)
+		j := githubOperator.findLabel(labels, labelName)
+		if j == -1 {
+			logging.Info(logging.Fields{Repo: repoName, Rule: splitMigrationRule}, "no label to split", labelName)
+			continue
+		}
+		issues := githubOperator.githubclient.FindIssuesByLabel(repoName, labelName)
+		remainingIssuesCount := 0
+		for k := 0; k < len(issues); k++ {
+			targetLabelName := githubOperator.issuestriage.FindSplitLabel(issues[k], rules, otherwiseLabelName)
+			if targetLabelName == "" {
+				remainingIssuesCount++
+				continue
+			}
+			steps = append(steps, githubOperator.relabelIssue(splitMigrationRule, repoName, issues[k], labels[j].Name, targetLabelName)...)
+		}
+		if remainingIssuesCount > 0 {
+			logging.Info(logging.Fields{Repo: repoName, Rule: splitMigrationRule}, "label", labelName, "is kept on", remainingIssuesCount, "issues")
+			continue
+		}
+		steps = append(steps, githubOperator.deleteLabel(splitMigrationRule, repoName, labels[j]))
+	}
+	return steps
+}
+
+type labelExpectation struct {
+	labelName string
+	exists    bool
+	label     *githubstructures.Label
+}
+
+type issueLabelExpectation struct {
+	issueUrl  string
+	labelName string
+	hasLabel  bool
+}
+
+func (githubOperator githuboperator) expectedLabelState(steps []githubstructures.LabelStep) ([]labelExpectation, []issueLabelExpectation) {
+	labelExpectations := []labelExpectation{}
+	labelIndexes := map[string]int{}
+	expectLabel := func(expectation labelExpectation) {
+		key := githubOperator.labelMatcher.Key(expectation.labelName)
+		if index, ok := labelIndexes[key]; ok {
+			labelExpectations[index] = expectation
+			return
+		}
+		labelIndexes[key] = len(labelExpectations)
+		labelExpectations = append(labelExpectations, expectation)
+	}
+	issueLabelExpectations := []issueLabelExpectation{}
+	issueLabelIndexes := map[string]int{}
+	expectIssueLabel := func(expectation issueLabelExpectation) {
+		key := expectation.issueUrl + " " + githubOperator.labelMatcher.Key(expectation.labelName)
+		if index, ok := issueLabelIndexes[key]; ok {
+			issueLabelExpectations[index] = expectation
+			return
+		}
+		issueLabelIndexes[key] = len(issueLabelExpectations)
+		issueLabelExpectations = append(issueLabelExpectations, expectation)
+	}
+	for i := 0; i < len(steps); i++ {
+		step := steps[i]
+		switch step.Kind {
+		case githubstructures.LabelStepKindEnum.RENAME_LABEL:
+			if !githubOperator.labelMatcher.Equal(step.LabelName, step.NewLabelName) {
+				expectLabel(labelExpectation{labelName: step.LabelName, exists: false})
+			}
+			expectLabel(labelExpectation{labelName: step.NewLabelName, exists: true})
+		case githubstructures.LabelStepKindEnum.UPDATE_LABEL:
+			after := step.After
+			expectLabel(labelExpectation{labelName: after.Name, exists: true, label: &after})
+		case githubstructures.LabelStepKindEnum.DELETE_LABEL:
+			expectLabel(labelExpectation{labelName: step.LabelName, exists: false})
+		case githubstructures.LabelStepKindEnum.CREATE_LABEL:
+			after := step.After
+			expectLabel(labelExpectation{labelName: after.Name, exists: true, label: &after})
+		case githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL:
+			expectIssueLabel(issueLabelExpectation{issueUrl: step.IssueUrl, labelName: step.LabelName, hasLabel: true})
+		case githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL:
+			expectIssueLabel(issueLabelExpectation{issueUrl: step.IssueUrl, labelName: step.LabelName, hasLabel: false})
+		}
+	}
+	return labelExpectations, issueLabelExpectations
+}
+
+func (githubOperator githuboperator) FindDrift(repoName string, steps []githubstructures.LabelStep) []string {
+	labelExpectations, issueLabelExpectations := githubOperator.expectedLabelState(steps)
+	drifts := []string{}
+	labels := githubOperator.githubclient.FindLabels(repoName)
+	for i := 0; i < len(labelExpectations); i++ {
+		expectation := labelExpectations[i]
+		j := githubOperator.findLabel(labels, expectation.labelName)
+		if expectation.exists && j == -1 {
+			drifts = append(drifts, repoName+": label \""+expectation.labelName+"\" is missing")
+		}
+		if !expectation.exists && j != -1 {
+			drifts = append(drifts, repoName+": label \""+expectation.labelName+"\" exists again")
+		}
+		if expectation.label != nil && j != -1 && (!labelcolor.Same(expectation.label.Color, labels[j].Color) || expectation.label.Description != labels[j].Description) {
+			drifts = append(drifts, repoName+": label \""+expectation.labelName+"\" has been changed")
+		}
+	}
+	labelledIssueUrls := map[string]map[string]bool{}
+	for i := 0; i < len(issueLabelExpectations); i++ {
+		expectation := issueLabelExpectations[i]
+		if githubOperator.findLabel(labels, expectation.labelName) == -1 {
+			continue
+		}
+		key := githubOperator.labelMatcher.Key(expectation.labelName)
+		if _, ok := labelledIssueUrls[key]; !ok {
+			labelledIssueUrls[key] = map[string]bool{}
+			labelledIssues := githubOperator.githubclient.FindIssuesByLabel(repoName, expectation.labelName)
+			for j := 0; j < len(labelledIssues); j++ {
+				labelledIssueUrls[key][labelledIssues[j].Url] = true
+			}
+		}
+		hasLabel := labelledIssueUrls[key][expectation.issueUrl]
+		if expectation.hasLabel && !hasLabel {
+			drifts = append(drifts, expectation.issueUrl+": label \""+expectation.labelName+"\" has been removed")
+		}
+		if !expectation.hasLabel && hasLabel {
+			drifts = append(drifts, expectation.issueUrl+": label \""+expectation.labelName+"\" has been added again")
+		}
+	}
+	return drifts
+}
+
+func (githubOperator githuboperator) RevertLabelSteps(repoName string, steps []githubstructures.LabelStep) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		logging.Info(logging.Fields{Repo: repoName, Issue: issueNumber(step.IssueUrl), Rule: rollbackRule}, "reverting", step)
+		switch step.Kind {
+		case githubstructures.LabelStepKindEnum.RENAME_LABEL:
+			githubOperator.renameRepoLabel(rollbackRule, repoName, step.NewLabelName, step.LabelName)
+		case githubstructures.LabelStepKindEnum.UPDATE_LABEL:
+			githubOperator.updateRepoLabel(rollbackRule, repoName, step.After, step.Before)
+		case githubstructures.LabelStepKindEnum.DELETE_LABEL:
+			githubOperator.createRepoLabel(rollbackRule, repoName, step.Before)
+		case githubstructures.LabelStepKindEnum.CREATE_LABEL:
+			githubOperator.deleteRepoLabel(rollbackRule, repoName, step.After)
+		case githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL:
+			githubOperator.removeIssueLabel(rollbackRule, repoName, step.IssueUrl, step.LabelName)
+		case githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL:
+			githubOperator.addIssueLabel(rollbackRule, repoName, step.IssueUrl, step.LabelName)
+		}
+	}
+}
+
+func (githubOperator githuboperator) SnapshotRepo(repoName string) githubstructures.RepoSnapshot {
+	labels := githubOperator.githubclient.FindLabels(repoName)
+	issues := githubOperator.githubclient.FindAllIssues(repoName)
+	issueSnapshots := []githubstructures.IssueSnapshot{}
+	for i := 0; i < len(issues); i++ {
+		labelNames := []string{}
+		for j := 0; j < len(issues[i].Labels); j++ {
+			labelNames = append(labelNames, issues[i].Labels[j].Name)
+		}
+		issueSnapshots = append(issueSnapshots, githubstructures.IssueSnapshot{
+			Number:        issues[i].Number,
+			IsPullRequest: issues[i].IsPullRequest,
+			LabelNames:    labelNames,
+		})
+	}
+	return githubstructures.RepoSnapshot{RepoName: repoName, Labels: labels, Issues: issueSnapshots}
+}
+
+func (githubOperator githuboperator) RestoreRepo(repoSnapshot githubstructures.RepoSnapshot, isDryRun bool) []githubstructures.LabelStep {
+	repoName := repoSnapshot.RepoName
+	steps := []githubstructures.LabelStep{}
+	labels := githubOperator.githubclient.FindLabels(repoName)
+	for i := 0; i < len(repoSnapshot.Labels); i++ {
+		label := repoSnapshot.Labels[i]
+		if githubOperator.findLabel(labels, label.Name) != -1 {
+			continue
+		}
+		if !isDryRun {
+			githubOperator.createRepoLabel(restoreRule, repoName, label)
+		}
+		steps = append(steps, githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.CREATE_LABEL, RepoName: repoName, LabelName: label.Name, After: label})
+	}
+	issues := githubOperator.githubclient.FindAllIssues(repoName)
+	issuesByNumber := map[int]githubstructures.Issue{}
+	for i := 0; i < len(issues); i++ {
+		issuesByNumber[issues[i].Number] = issues[i]
+	}
+	for i := 0; i < len(repoSnapshot.Issues); i++ {
+		issueSnapshot := repoSnapshot.Issues[i]
+		issue, ok := issuesByNumber[issueSnapshot.Number]
+		if !ok {
+			logging.Warn(logging.Fields{Repo: repoName, Issue: issueSnapshot.Number, Rule: restoreRule}, "issue no longer exists")
+			continue
+		}
+		for j := 0; j < len(issueSnapshot.LabelNames); j++ {
+			labelName := issueSnapshot.LabelNames[j]
+			if githubOperator.findLabel(issue.Labels, labelName) != -1 {
+				continue
+			}
+			if !isDryRun {
+				githubOperator.addIssueLabel(restoreRule, repoName, issue.Url, labelName)
+			}
+			steps = append(steps, githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: repoName, IssueUrl: issue.Url, LabelName: labelName})
+		}
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) applyLabels(rule string, repoName string, allLabels []githubstructures.Label, labels []githubstructures.Label, isPruning bool) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(labels); i++ {
+		label := labels[i]
+		j := githubOperator.findLabel(allLabels, label.Name)
+		if j == -1 {
+			githubOperator.createRepoLabel(rule, repoName, label)
+			steps = append(steps, githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.CREATE_LABEL, RepoName: repoName, LabelName: label.Name, After: label}))
+			continue
+		}
+		if allLabels[j].Name != label.Name {
+			logging.Warn(logging.Fields{Repo: repoName, Rule: rule, Operation: "RenameLabel"}, "planned: rename", strconv.Quote(allLabels[j].Name), "to", strconv.Quote(label.Name), "with the labels merge command")
+			label.Name = allLabels[j].Name
+		}
+		if labelcolor.Same(allLabels[j].Color, label.Color) && allLabels[j].Description == label.Description {
+			continue
+		}
+		githubOperator.updateRepoLabel(rule, repoName, allLabels[j], label)
+		steps = append(steps, githubOperator.appliedStep(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.UPDATE_LABEL, RepoName: repoName, LabelName: allLabels[j].Name, Before: allLabels[j], After: label}))
+	}
+	if !isPruning {
+		return steps
+	}
+	for i := 0; i < len(allLabels); i++ {
+		if githubOperator.findLabel(labels, allLabels[i].Name) == -1 {
+			steps = append(steps, githubOperator.deleteLabel(rule, repoName, allLabels[i]))
+		}
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) ApplyLabelsInEachRepo(repoNames []string, labels []githubstructures.Label, isPruning bool) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(repoNames); i++ {
+		repoSteps := githubOperator.applyLabels(importRule, repoNames[i], githubOperator.githubclient.FindLabels(repoNames[i]), labels, isPruning)
+		logging.Info(logging.Fields{Repo: repoNames[i], Rule: importRule}, "applied labels", len(repoSteps), "changes")
+		steps = append(steps, repoSteps...)
+	}
+	return steps
+}
+
+func (githubOperator githuboperator) findNearDuplicates(labels []githubstructures.Label, managedLabels []githubstructures.Label) [][]string {
+	keys := []string{}
+	groups := map[string][]string{}
+	isManagedOnly := map[string]bool{}
+	for i := 0; i < len(labels); i++ {
+		key := githubOperator.labelMatcher.Value(labels[i].Name)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			isManagedOnly[key] = true
+		}
+		groups[key] = append(groups[key], labels[i].Name)
+		isManagedOnly[key] = isManagedOnly[key] && githubOperator.findLabel(managedLabels, labels[i].Name) != -1
+	}
+	nearDuplicates := [][]string{}
+	for i := 0; i < len(keys); i++ {
+		if len(groups[keys[i]]) > 1 && !isManagedOnly[keys[i]] {
+			nearDuplicates = append(nearDuplicates, groups[keys[i]])
+		}
+	}
+	return nearDuplicates
+}
+
+func (githubOperator githuboperator) AuditLabels(repoNames []string) []githubstructures.LabelAudit {
+	managedLabels := githubOperator.managedLabels()
+	audits := []githubstructures.LabelAudit{}
+	for i := 0; i < len(repoNames); i++ {
+		allLabels := githubOperator.githubclient.FindLabels(repoNames[i])
+		audit := githubstructures.LabelAudit{
+			RepoName:         repoNames[i],
+			MissingLabels:    []githubstructures.Label{},
+			MismatchedLabels: []githubstructures.LabelMismatch{},
+			ExtraLabels:      []githubstructures.Label{},
+			NearDuplicates:   githubOperator.findNearDuplicates(allLabels, managedLabels),
+		}
+		for j := 0; j < len(managedLabels); j++ {
+			k := githubOperator.findLabel(allLabels, managedLabels[j].Name)
+			if k == -1 {
+				audit.MissingLabels = append(audit.MissingLabels, managedLabels[j])
+				continue
+			}
+			if !labelcolor.Same(managedLabels[j].Color, allLabels[k].Color) || managedLabels[j].Description != allLabels[k].Description {
+				audit.MismatchedLabels = append(audit.MismatchedLabels, githubstructures.LabelMismatch{Expected: managedLabels[j], Actual: allLabels[k]})
+			}
+		}
+		for j := 0; j < len(allLabels); j++ {
+			if githubOperator.findLabel(managedLabels, allLabels[j].Name) == -1 {
+				audit.ExtraLabels = append(audit.ExtraLabels, allLabels[j])
+			}
+		}
+		audits = append(audits, audit)
+	}
+	return audits
+}
+
+func (githubOperator githuboperator) PlanLabelPrune(repoNames []string, taxonomyLabels []githubstructures.Label) []githubstructures.LabelPrunePlan {
+	keptLabels := append(githubOperator.managedLabels(), taxonomyLabels...)
+	plans := []githubstructures.LabelPrunePlan{}
+	for i := 0; i < len(repoNames); i++ {
+		usages := githubOperator.githubclient.FindLabelUsages(repoNames[i])
+		plan := githubstructures.LabelPrunePlan{
+			RepoName:       repoNames[i],
+			LabelsToDelete: []githubstructures.LabelUsage{},
+			LabelsToMerge:  []githubstructures.LabelMergeProposal{},
+		}
+		keys := []string{}
+		groups := map[string][]githubstructures.LabelUsage{}
+		for j := 0; j < len(usages); j++ {
+			key := githubOperator.labelMatcher.Value(usages[j].Label.Name)
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], usages[j])
+		}
+		mergedLabelNames := map[string]bool{}
+		for j := 0; j < len(keys); j++ {
+			group := groups[keys[j]]
+			canonicalLabels := []githubstructures.Label{}
+			for k := 0; k < len(group); k++ {
+				l := githubOperator.findLabel(keptLabels, group[k].Label.Name)
+				if l != -1 && githubOperator.findLabel(canonicalLabels, keptLabels[l].Name) == -1 {
+					canonicalLabels = append(canonicalLabels, keptLabels[l])
+				}
+			}
+			if len(group) < 2 || len(canonicalLabels) != 1 {
+				continue
+			}
+			for k := 0; k < len(group); k++ {
+				if group[k].Label.Name != canonicalLabels[0].Name {
+					plan.LabelsToMerge = append(plan.LabelsToMerge, githubstructures.LabelMergeProposal{Source: group[k], TargetLabelName: canonicalLabels[0].Name})
+					mergedLabelNames[group[k].Label.Name] = true
+				}
+			}
+		}
+		for j := 0; j < len(usages); j++ {
+			if usages[j].UsesCount == 0 && !mergedLabelNames[usages[j].Label.Name] && githubOperator.findLabel(keptLabels, usages[j].Label.Name) == -1 {
+				plan.LabelsToDelete = append(plan.LabelsToDelete, usages[j])
+			}
+		}
+		logging.Info(logging.Fields{Repo: repoNames[i], Rule: pruneRule}, "prune plan", len(plan.LabelsToDelete), "labels to delete", len(plan.LabelsToMerge), "labels to merge")
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+func (githubOperator githuboperator) ApplyLabelPrune(plans []githubstructures.LabelPrunePlan) []githubstructures.LabelStep {
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(plans); i++ {
+		plan := plans[i]
+		for j := 0; j < len(plan.LabelsToMerge); j++ {
+			steps = append(steps, githubOperator.mergeLabelInRepo(pruneRule, plan.RepoName, plan.LabelsToMerge[j].Source.Label.Name, plan.LabelsToMerge[j].TargetLabelName)...)
+		}
+		for j := 0; j < len(plan.LabelsToDelete); j++ {
+			steps = append(steps, githubOperator.deleteUnusedLabelInRepo(pruneRule, plan.RepoName, plan.LabelsToDelete[j].Label.Name)...)
+		}
 	}
+	return steps
 }
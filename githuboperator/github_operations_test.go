@@ -1,18 +1,46 @@
 package githuboperator
 
 import (
+	"fmt"
 	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/labelmatch"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"log"
 	"os"
 	"testing"
+	"text/template"
+	"time"
 )
 
 type Mockissuestriage struct{}
 
+var mockTriageOneIssueByAnswering func(issue githubstructures.Issue) githubstructures.TriageDecision
+var mockTriageOneIssueByManualLabel func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.TriageDecision
+var mockTriageOneIssueByStaleness func(issue githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) githubstructures.TriageDecision
 var mockGroupByAnswering func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue)
 var mockGroupByManualLabel func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue)
+var mockWaitingSince func(issue githubstructures.Issue) time.Time
+var mockTriageOneIssueByEscalation func(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int
+var mockEscalationMarker func(stage int) string
+var mockGroupByStaleness func(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue)
+var mockStaleMarker func() string
+var mockResolveExclusiveGroup func(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool)
+var mockFindOrphanedLabels func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string
+var mockFindSplitLabel func(issue githubstructures.Issue, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) string
+var mockGroupByDeadline func(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue)
+
+func (issuesTriage Mockissuestriage) TriageOneIssueByAnswering(issue githubstructures.Issue) githubstructures.TriageDecision {
+	return mockTriageOneIssueByAnswering(issue)
+}
+
+func (issuesTriage Mockissuestriage) TriageOneIssueByManualLabel(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.TriageDecision {
+	return mockTriageOneIssueByManualLabel(issue, config)
+}
+
+func (issuesTriage Mockissuestriage) TriageOneIssueByStaleness(issue githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) githubstructures.TriageDecision {
+	return mockTriageOneIssueByStaleness(issue, config, now)
+}
 
 func (issuesTriage Mockissuestriage) GroupByAnswering(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
 	return mockGroupByAnswering(issues)
@@ -22,6 +50,66 @@ func (issuesTriage Mockissuestriage) GroupByManualLabel(issues []githubstructure
 	return mockGroupByManualLabel(issues, config)
 }
 
+func (issuesTriage Mockissuestriage) WaitingSince(issue githubstructures.Issue) time.Time {
+	return mockWaitingSince(issue)
+}
+
+func (issuesTriage Mockissuestriage) TriageOneIssueByEscalation(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int {
+	return mockTriageOneIssueByEscalation(issue, stages, waitingTime)
+}
+
+func (issuesTriage Mockissuestriage) EscalationMarker(stage int) string {
+	return mockEscalationMarker(stage)
+}
+
+func (issuesTriage Mockissuestriage) GroupByStaleness(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+	return mockGroupByStaleness(issues, config, now)
+}
+
+func (issuesTriage Mockissuestriage) StaleMarker() string {
+	return mockStaleMarker()
+}
+
+func (issuesTriage Mockissuestriage) ResolveExclusiveGroup(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool) {
+	return mockResolveExclusiveGroup(issue, group)
+}
+
+func (issuesTriage Mockissuestriage) FindOrphanedLabels(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string {
+	return mockFindOrphanedLabels(issue, config)
+}
+
+func (issuesTriage Mockissuestriage) FindSplitLabel(issue githubstructures.Issue, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) string {
+	return mockFindSplitLabel(issue, rules, otherwiseLabelName)
+}
+
+func (issuesTriage Mockissuestriage) GroupByDeadline(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue) {
+	return mockGroupByDeadline(issues, deadlines, calendar, now)
+}
+
+type Mockrepoconfigs struct{}
+
+var mockForRepo func(repoName string) githubstructures.RepoConfig
+
+func (repoConfigs Mockrepoconfigs) ForRepo(repoName string) githubstructures.RepoConfig {
+	return mockForRepo(repoName)
+}
+
+type Mockclock struct{}
+
+var mockNow func() time.Time
+
+func (clock Mockclock) Now() time.Time {
+	return mockNow()
+}
+
+type Mockauditor struct{}
+
+var mockRecord func(entry githubstructures.AuditEntry)
+
+func (auditor Mockauditor) Record(entry githubstructures.AuditEntry) {
+	mockRecord(entry)
+}
+
 type Mockgithubclient struct{}
 
 var mockFindRepos func() []string
@@ -31,7 +119,14 @@ var mockCreateLabel func(repoName string, label githubstructures.Label)
 var mockRemoveLabel func(issueUrl string, labelName string)
 var mockAddLabel func(issueUrl string, labelName string)
 var mockRenameLabel func(repoName string, oldLabelName string, newLabelName string)
+var mockUpdateLabel func(repoName string, labelName string, label githubstructures.Label)
 var mockFindIssues func(repoName string) []githubstructures.Issue
+var mockFindIssue func(repoName string, number int) (githubstructures.Issue, bool)
+var mockFindIssuesByLabel func(repoName string, labelName string) []githubstructures.Issue
+var mockFindAllIssues func(repoName string) []githubstructures.Issue
+var mockFindLabelUsages func(repoName string) []githubstructures.LabelUsage
+var mockCreateComment func(issueUrl string, body string)
+var mockCloseIssue func(issueUrl string)
 
 func (githubClient Mockgithubclient) FindRepos() []string {
 	return mockFindRepos()
@@ -54,9 +149,30 @@ func (githubClient Mockgithubclient) AddLabel(issueUrl string, labelName string)
 func (githubClient Mockgithubclient) RenameLabel(repoName string, oldLabelName string, newLabelName string) {
 	mockRenameLabel(repoName, oldLabelName, newLabelName)
 }
+func (githubClient Mockgithubclient) UpdateLabel(repoName string, labelName string, label githubstructures.Label) {
+	mockUpdateLabel(repoName, labelName, label)
+}
 func (githubClient Mockgithubclient) FindIssues(repoName string) []githubstructures.Issue {
 	return mockFindIssues(repoName)
 }
+func (githubClient Mockgithubclient) FindIssue(repoName string, number int) (githubstructures.Issue, bool) {
+	return mockFindIssue(repoName, number)
+}
+func (githubClient Mockgithubclient) FindIssuesByLabel(repoName string, labelName string) []githubstructures.Issue {
+	return mockFindIssuesByLabel(repoName, labelName)
+}
+func (githubClient Mockgithubclient) FindAllIssues(repoName string) []githubstructures.Issue {
+	return mockFindAllIssues(repoName)
+}
+func (githubClient Mockgithubclient) FindLabelUsages(repoName string) []githubstructures.LabelUsage {
+	return mockFindLabelUsages(repoName)
+}
+func (githubClient Mockgithubclient) CreateComment(issueUrl string, body string) {
+	mockCreateComment(issueUrl, body)
+}
+func (githubClient Mockgithubclient) CloseIssue(issueUrl string) {
+	mockCloseIssue(issueUrl)
+}
 
 func TestMain(m *testing.M) {
 	status := m.Run()
@@ -79,6 +195,8 @@ func TestGithubOperator(t *testing.T) {
 }
 
 var _ = Describe("githuboperator", func() {
+	labelMatcher := labelmatch.New(labelmatch.DefaultSeparators)
+
 	BeforeEach(func() {
 		mockFindRepos = func() []string {
 			Fail("mockFindRepos not implemented")
@@ -100,10 +218,48 @@ var _ = Describe("githuboperator", func() {
 		mockAddLabel = func(issueUrl string, labelName string) {
 			Fail("mockAddLabel not implemented")
 		}
+		mockUpdateLabel = func(repoName string, labelName string, label githubstructures.Label) {
+			Fail("mockUpdateLabel not implemented")
+		}
 		mockFindIssues = func(repoName string) []githubstructures.Issue {
 			Fail("mockFindIssues not implemented")
 			return nil
 		}
+		mockFindIssue = func(repoName string, number int) (githubstructures.Issue, bool) {
+			Fail("mockFindIssue not implemented")
+			return githubstructures.Issue{}, false
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			Fail("mockFindIssuesByLabel not implemented")
+			return nil
+		}
+		mockFindAllIssues = func(repoName string) []githubstructures.Issue {
+			Fail("mockFindAllIssues not implemented")
+			return nil
+		}
+		mockFindLabelUsages = func(repoName string) []githubstructures.LabelUsage {
+			Fail("mockFindLabelUsages not implemented")
+			return nil
+		}
+		mockCreateComment = func(issueUrl string, body string) {
+			Fail("mockCreateComment not implemented")
+		}
+		mockCloseIssue = func(issueUrl string) {
+			Fail("mockCloseIssue not implemented")
+		}
+		mockWaitingSince = func(issue githubstructures.Issue) time.Time {
+			return time.Time{}
+		}
+		mockGroupByDeadline = func(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue) {
+			return []githubstructures.DeadlineBreach{}, issues
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{OverdueLabelName: "overdue"}
+		}
+		mockNow = func() time.Time {
+			return time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+		}
+		mockRecord = func(entry githubstructures.AuditEntry) {}
 	})
 
 	It("triages an empty list", func() {
@@ -116,7 +272,7 @@ var _ = Describe("githuboperator", func() {
 
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 		githubOperator.UpdateRepos(repoNames)
 	})
 
@@ -147,7 +303,7 @@ var _ = Describe("githuboperator", func() {
 		}
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
 		githubOperator.UpdateRepos(repoNames)
 
@@ -209,7 +365,7 @@ var _ = Describe("githuboperator", func() {
 		}
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
 		githubOperator.UpdateRepos(repoNames)
 
@@ -220,8 +376,8 @@ var _ = Describe("githuboperator", func() {
 		}))
 	})
 
-	It("deletes invalid labels", func() {
-		mockDeleteLabelsParams := []interface{}{}
+	It("updates invalid labels in place", func() {
+		mockUpdateLabelParams := []interface{}{}
 		repoNames := []string{
 			"repo-1",
 			"repo-2",
@@ -260,22 +416,22 @@ var _ = Describe("githuboperator", func() {
 		}
 		mockCreateLabel = func(repoName string, label githubstructures.Label) {
 		}
-		mockDeleteLabel = func(repoName string, labelName string) {
-			mockDeleteLabelsParams = append(mockDeleteLabelsParams, []interface{}{repoName, labelName})
+		mockUpdateLabel = func(repoName string, labelName string, label githubstructures.Label) {
+			mockUpdateLabelParams = append(mockUpdateLabelParams, []interface{}{repoName, labelName, label})
 		}
 		mockFindIssues = func(repoName string) []githubstructures.Issue {
 			return []githubstructures.Issue{}
 		}
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
 		githubOperator.UpdateRepos(repoNames)
 
-		Expect(mockDeleteLabelsParams).To(Equal([]interface{}{
-			[]interface{}{"repo-1", "label-1"},
-			[]interface{}{"repo-3", "label-2"},
-			[]interface{}{"repo-3", "label-3"},
+		Expect(mockUpdateLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "label-1", githubstructures.Label{Name: "label-1", Color: "color-1"}},
+			[]interface{}{"repo-3", "label-2", githubstructures.Label{Name: "label-2", Color: "color-2"}},
+			[]interface{}{"repo-3", "label-3", githubstructures.Label{Name: "label-3", Color: "color-3"}},
 		}))
 	})
 
@@ -298,12 +454,12 @@ var _ = Describe("githuboperator", func() {
 		}
 		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
 			return []githubstructures.Issue{
-					githubstructures.Issue{Url: "url-1"},
+					githubstructures.Issue{Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "Missing Severity"}}},
 					githubstructures.Issue{Url: "url-2"},
 				},
 				[]githubstructures.Issue{
 					githubstructures.Issue{Url: "url-3"},
-					githubstructures.Issue{Url: "url-4"},
+					githubstructures.Issue{Url: "url-4", Labels: []githubstructures.Label{githubstructures.Label{Name: "missing severity"}}},
 				}
 		}
 		mockFindLabels = func(repoName string) []githubstructures.Label {
@@ -331,20 +487,233 @@ var _ = Describe("githuboperator", func() {
 			"not-answered",
 			answeringLabels,
 			[]githubstructures.ManualLabelConfig{githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}},
+			Mockrepoconfigs{},
+			Mockclock{},
+			Mockauditor{},
+			labelMatcher,
 		)
 
 		githubOperator.UpdateRepos(repoNames)
 
 		Expect(mockRemoveLabelParams).To(Equal([]interface{}{
-			[]interface{}{"url-1", "missing severity"},
-			[]interface{}{"url-2", "missing severity"},
+			[]interface{}{"url-1", "Missing Severity"},
 		}))
 		Expect(mockAddLabelParams).To(Equal([]interface{}{
 			[]interface{}{"url-3", "missing severity"},
-			[]interface{}{"url-4", "missing severity"},
 		}))
 	})
 
+	It("creates, updates and deletes missing manual labels", func() {
+		mockCreateLabelsParams := []interface{}{}
+		mockDeleteLabelParams := []interface{}{}
+		mockUpdateLabelParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "label-1", Color: "color-1"},
+			githubstructures.Label{Name: "label-2", Color: "color-2"},
+			githubstructures.Label{Name: "label-3", Color: "color-3"},
+		}
+		defaultLabels := append([]githubstructures.Label{
+			githubstructures.Label{Name: "missing docs", Color: "color-4"},
+		}, answeringLabels...)
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type", MissingLabelColor: "color-5", MissingLabelDescription: "description-5"},
+			githubstructures.ManualLabelConfig{Prefix: "severity", MissingLabelColor: "color-6", MissingLabelDescription: "description-6"},
+			githubstructures.ManualLabelConfig{Prefix: "docs", MissingLabelColor: "color-7"},
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return append([]githubstructures.Label{
+				githubstructures.Label{Name: "missing docs", Color: "color-4"},
+				githubstructures.Label{Name: "Missing priority", Color: "ededed", Description: "Needs a priority (managed by issue-overseer)"},
+				githubstructures.Label{Name: "missing repro", Color: "ededed", Description: "Needs steps to reproduce"},
+				githubstructures.Label{Name: "missing type", Color: "ededed"},
+				githubstructures.Label{Name: "missing severity", Color: "color-6"},
+			}, answeringLabels...)
+		}
+		mockCreateLabel = func(repoName string, label githubstructures.Label) {
+			mockCreateLabelsParams = append(mockCreateLabelsParams, []interface{}{repoName, label})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockDeleteLabelParams = append(mockDeleteLabelParams, []interface{}{repoName, labelName})
+		}
+		mockUpdateLabel = func(repoName string, labelName string, label githubstructures.Label) {
+			mockUpdateLabelParams = append(mockUpdateLabelParams, []interface{}{repoName, labelName, label})
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{}
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", defaultLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos(repoNames)
+
+		Expect(mockUpdateLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "missing type", githubstructures.Label{Name: "missing type", Color: "color-5", Description: "description-5 (managed by issue-overseer)"}},
+			[]interface{}{"repo-1", "missing severity", githubstructures.Label{Name: "missing severity", Color: "color-6", Description: "description-6 (managed by issue-overseer)"}},
+		}))
+		Expect(mockDeleteLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "Missing priority"},
+		}))
+		Expect(mockCreateLabelsParams).To(Equal([]interface{}{}))
+	})
+
+	It("keeps the missing labels it doesn't manage", func() {
+		mockDeleteLabelParams := []interface{}{}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "label-1", Color: "color-1"},
+			githubstructures.Label{Name: "label-2", Color: "color-2"},
+			githubstructures.Label{Name: "label-3", Color: "color-3"},
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return append([]githubstructures.Label{
+				githubstructures.Label{Name: "missing docs", Color: "c5def5", Description: "The docs have to be written"},
+				githubstructures.Label{Name: "missing priority", Color: "ededed", Description: "(managed by issue-overseer)"},
+			}, answeringLabels...)
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockDeleteLabelParams = append(mockDeleteLabelParams, []interface{}{repoName, labelName})
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{}
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "label-1", "label-2", "label-3", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos([]string{"repo-1"})
+
+		Expect(mockDeleteLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "missing priority"},
+		}))
+	})
+
+	It("doesn't change or audit issues whose labels are already right", func() {
+		mockAddLabelParams := []interface{}{}
+		mockRemoveLabelParams := []interface{}{}
+		recordedEntries := []githubstructures.AuditEntry{}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		issues := []githubstructures.Issue{
+			githubstructures.Issue{Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "Answered"}, githubstructures.Label{Name: "missing severity"}}},
+			githubstructures.Issue{Url: "url-2", Labels: []githubstructures.Label{githubstructures.Label{Name: "answered"}, githubstructures.Label{Name: "severity: major"}}},
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, issues, []githubstructures.Issue{}
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			return issues[1:], issues[:1]
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return append(answeringLabels, githubstructures.Label{Name: "missing severity", Color: "ff0000", Description: "(managed by issue-overseer)"})
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return issues
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockAddLabelParams = append(mockAddLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRemoveLabelParams = append(mockRemoveLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRecord = func(entry githubstructures.AuditEntry) {
+			recordedEntries = append(recordedEntries, entry)
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{githubstructures.ManualLabelConfig{Prefix: "severity", MissingLabelColor: "ff0000"}}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		summary := githubOperator.UpdateRepos([]string{"repo-1"})
+
+		Expect(mockAddLabelParams).To(BeEmpty())
+		Expect(mockRemoveLabelParams).To(BeEmpty())
+		Expect(recordedEntries).To(BeEmpty())
+		Expect(summary.Repos[0].IssueLabelChangesCount).To(Equal(0))
+		Expect(summary.Repos[0].LabelsCreatedCount + summary.Repos[0].LabelsUpdatedCount + summary.Repos[0].LabelsDeletedCount).To(Equal(0))
+	})
+
+	It("lets later rules see the labels changed by earlier rules", func() {
+		mockAddLabelParams := []interface{}{}
+		mockRemoveLabelParams := []interface{}{}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.REMOVE},
+		}
+		severityGroup := githubstructures.ExclusiveGroup{Prefix: "severity", Policy: githubstructures.ExclusiveGroupPolicyEnum.LATEST}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, issues
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{OverdueLabelName: "overdue", ExclusiveGroups: []githubstructures.ExclusiveGroup{severityGroup}}
+		}
+		mockResolveExclusiveGroup = func(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool) {
+			return []string{"severity: minor"}, false
+		}
+		mockFindOrphanedLabels = func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string {
+			labelNames := []string{}
+			for i := 0; i < len(issue.Labels); i++ {
+				labelNames = append(labelNames, issue.Labels[i].Name)
+			}
+			return labelNames
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockCreateLabel = func(repoName string, label githubstructures.Label) {
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "severity: major"}, githubstructures.Label{Name: "severity: minor"}}},
+			}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockAddLabelParams = append(mockAddLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRemoveLabelParams = append(mockRemoveLabelParams, []interface{}{issueUrl, labelName})
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		summary := githubOperator.UpdateRepos([]string{"repo-1"})
+
+		Expect(mockRemoveLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "severity: minor"},
+			[]interface{}{"url-1", "severity: major"},
+		}))
+		Expect(mockAddLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "missing severity"},
+		}))
+		Expect(summary.Repos[0].OrphanedLabelRemovals).To(Equal([]githubstructures.LabelChange{githubstructures.LabelChange{IssueUrl: "url-1", LabelName: "severity: major"}}))
+	})
+
 	It("adds missing labels", func() {
 		mockAddLabelParams := []interface{}{}
 		repoNames := []string{
@@ -385,7 +754,7 @@ var _ = Describe("githuboperator", func() {
 		}
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
 		githubOperator.UpdateRepos(repoNames)
 
@@ -454,7 +823,7 @@ var _ = Describe("githuboperator", func() {
 		}
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
 		githubOperator.UpdateRepos(repoNames)
 
@@ -472,12 +841,10 @@ var _ = Describe("githuboperator", func() {
 		}))
 	})
 
-	It("renames labels", func() {
-		mockRenameLabelParams := []interface{}{}
+	It("computes the waiting time with the repo calendar", func() {
+		mockForRepoParams := []interface{}{}
 		repoNames := []string{
 			"repo-1",
-			"repo-2",
-			"repo-3",
 		}
 		answeringLabels := []githubstructures.Label{
 			githubstructures.Label{Name: "by-ours", Color: "color-1"},
@@ -485,20 +852,1483 @@ var _ = Describe("githuboperator", func() {
 			githubstructures.Label{Name: "not-answered", Color: "color-3"},
 		}
 
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{},
+				[]githubstructures.Issue{},
+				[]githubstructures.Issue{
+					githubstructures.Issue{Url: "url-1"},
+				}
+		}
+		mockWaitingSince = func(issue githubstructures.Issue) time.Time {
+			return time.Date(2020, 7, 10, 16, 0, 0, 0, time.UTC)
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			mockForRepoParams = append(mockForRepoParams, repoName)
+			return githubstructures.RepoConfig{Calendar: githubstructures.CalendarConfig{
+				Location:          time.UTC,
+				WorkingDays:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+				WorkingHoursStart: 9 * time.Hour,
+				WorkingHoursEnd:   17 * time.Hour,
+			}}
+		}
+		mockNow = func() time.Time {
+			return time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+		}
 		githubClient := Mockgithubclient{}
 		issuesTriage := Mockissuestriage{}
-		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil)
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
-		mockRenameLabel = func(repoName string, oldLabelName string, newLabelName string) {
-			mockRenameLabelParams = append(mockRenameLabelParams, []interface{}{repoName, oldLabelName, newLabelName})
+		githubOperator.UpdateRepos(repoNames)
+
+		Expect(mockForRepoParams).To(ContainElement("repo-1"))
+		Expect(githubOperator.waitingTime("repo-1", githubstructures.Issue{Url: "url-1"})).To(Equal(2 * time.Hour))
+	})
+
+	It("adds and removes overdue labels", func() {
+		mockAddLabelParams := []interface{}{}
+		mockRemoveLabelParams := []interface{}{}
+		mockGroupByDeadlineParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		deadlines := map[string]githubstructures.SeverityDeadline{
+			"critical": githubstructures.SeverityDeadline{Response: 4 * time.Hour},
 		}
 
-		githubOperator.RenameLabelInEachRepo(repoNames, "old-1", "new-1")
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{SeverityDeadlines: deadlines, OverdueLabelName: "late"}
+		}
+		mockGroupByDeadline = func(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue) {
+			mockGroupByDeadlineParams = append(mockGroupByDeadlineParams, []interface{}{append([]githubstructures.Issue{}, issues...), deadlines, now})
+			return []githubstructures.DeadlineBreach{
+					githubstructures.DeadlineBreach{Issue: githubstructures.Issue{Url: "url-1"}, Severity: "critical", Kind: "response", MissedBy: 2 * time.Hour},
+					githubstructures.DeadlineBreach{Issue: githubstructures.Issue{Url: "url-2", Labels: []githubstructures.Label{githubstructures.Label{Name: "late"}}}, Severity: "critical", Kind: "response", MissedBy: time.Hour},
+				},
+				[]githubstructures.Issue{
+					githubstructures.Issue{Url: "url-3"},
+					githubstructures.Issue{Url: "url-4", Labels: []githubstructures.Label{githubstructures.Label{Name: "late"}}},
+				}
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{githubstructures.Issue{Url: "url-1"}}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockAddLabelParams = append(mockAddLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRemoveLabelParams = append(mockRemoveLabelParams, []interface{}{issueUrl, labelName})
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
 
-		Expect(mockRenameLabelParams).To(Equal([]interface{}{
-			[]interface{}{"repo-1", "old-1", "new-1"},
-			[]interface{}{"repo-2", "old-1", "new-1"},
-			[]interface{}{"repo-3", "old-1", "new-1"},
+		githubOperator.UpdateRepos(repoNames)
+
+		Expect(mockGroupByDeadlineParams).To(Equal([]interface{}{
+			[]interface{}{[]githubstructures.Issue{githubstructures.Issue{Url: "url-1"}}, deadlines, time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)},
 		}))
+		Expect(mockAddLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "late"},
+		}))
+		Expect(mockRemoveLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-4", "late"},
+		}))
+	})
+
+	It("posts escalation comments", func() {
+		mockCreateCommentParams := []interface{}{}
+		mockTriageOneIssueByEscalationParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		stages := []time.Duration{8 * time.Hour, 24 * time.Hour}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, issues
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{
+				Calendar:         githubstructures.CalendarConfig{WorkingDays: []time.Weekday{time.Monday, time.Friday}, WorkingHoursEnd: 24 * time.Hour},
+				OverdueLabelName: "overdue",
+				Escalation: githubstructures.EscalationConfig{
+					Mention:  "@on-call",
+					Template: template.Must(template.New("escalation").Option("missingkey=error").Parse("{{.Mention}} @{{.Reporter}} reported {{.Title}} {{.Age}} ago and waits {{.WaitingHours}}h (stage {{.Stage}})")),
+					Stages:   stages,
+				},
+			}
+		}
+		mockWaitingSince = func(issue githubstructures.Issue) time.Time {
+			return time.Date(2020, 7, 10, 10, 0, 0, 0, time.UTC)
+		}
+		mockTriageOneIssueByEscalation = func(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int {
+			mockTriageOneIssueByEscalationParams = append(mockTriageOneIssueByEscalationParams, []interface{}{issue.Url, stages, waitingTime})
+			if issue.Url == "url-1" {
+				return 2
+			}
+			return 0
+		}
+		mockEscalationMarker = func(stage int) string {
+			return "<marker-" + fmt.Sprint(stage) + ">"
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1", Title: "crash", AuthorLogin: "reporter", CreatedAt: time.Date(2020, 7, 9, 8, 0, 0, 0, time.UTC)},
+				githubstructures.Issue{Url: "url-2", Title: "typo", AuthorLogin: "reporter", CreatedAt: time.Date(2020, 7, 13, 8, 0, 0, 0, time.UTC)},
+			}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+		}
+		mockCreateComment = func(issueUrl string, body string) {
+			mockCreateCommentParams = append(mockCreateCommentParams, []interface{}{issueUrl, body})
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos(repoNames)
+
+		Expect(mockTriageOneIssueByEscalationParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", stages, 24 * time.Hour},
+			[]interface{}{"url-2", stages, 24 * time.Hour},
+		}))
+		Expect(mockCreateCommentParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "@on-call @reporter reported crash 4d 2h ago and waits 24h (stage 2)\n\n<marker-2>"},
+		}))
+	})
+
+	It("skips escalation comments with a failing template", func() {
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, issues
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{
+				OverdueLabelName: "overdue",
+				Escalation: githubstructures.EscalationConfig{
+					Mention:  "@on-call",
+					Template: template.Must(template.New("escalation").Parse("{{.Mention.Nope}}")),
+					Stages:   []time.Duration{time.Hour},
+				},
+			}
+		}
+		mockTriageOneIssueByEscalation = func(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int {
+			return 1
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1", CreatedAt: time.Date(2020, 7, 13, 8, 0, 0, 0, time.UTC)},
+			}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos(repoNames)
+	})
+
+	It("labels, warns, revives and closes stale issues", func() {
+		mockAddLabelParams := []interface{}{}
+		mockRemoveLabelParams := []interface{}{}
+		mockCreateCommentParams := []interface{}{}
+		mockCloseIssueParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		stale := githubstructures.StaleConfig{
+			After:      30 * 24 * time.Hour,
+			CloseAfter: 7 * 24 * time.Hour,
+			LabelName:  "abandoned",
+			Warning:    template.Must(template.New("stale").Parse("@{{.Reporter}} {{.Days}} days, closing in {{.CloseAfterDays}} days")),
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{OverdueLabelName: "overdue", Stale: stale}
+		}
+		mockGroupByStaleness = func(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			Expect(config).To(Equal(stale))
+			Expect(now).To(Equal(time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)))
+			return []githubstructures.Issue{
+					githubstructures.Issue{Url: "url-1", AuthorLogin: "reporter"},
+				},
+				[]githubstructures.Issue{
+					githubstructures.Issue{Url: "url-2", Labels: []githubstructures.Label{githubstructures.Label{Name: "abandoned"}}},
+				},
+				[]githubstructures.Issue{
+					githubstructures.Issue{Url: "url-3"},
+				}
+		}
+		mockStaleMarker = func() string {
+			return "<stale-marker>"
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockAddLabelParams = append(mockAddLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRemoveLabelParams = append(mockRemoveLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockCreateComment = func(issueUrl string, body string) {
+			mockCreateCommentParams = append(mockCreateCommentParams, []interface{}{issueUrl, body})
+		}
+		mockCloseIssue = func(issueUrl string) {
+			mockCloseIssueParams = append(mockCloseIssueParams, issueUrl)
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos(repoNames)
+
+		Expect(mockAddLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "abandoned"},
+		}))
+		Expect(mockCreateCommentParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "@reporter 30 days, closing in 7 days\n\n<stale-marker>"},
+		}))
+		Expect(mockRemoveLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-2", "abandoned"},
+		}))
+		Expect(mockCloseIssueParams).To(Equal([]interface{}{"url-3"}))
+	})
+
+	It("skips stale warnings with a failing template", func() {
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{OverdueLabelName: "overdue", Stale: githubstructures.StaleConfig{
+				After:     24 * time.Hour,
+				LabelName: "stale",
+				Warning:   template.Must(template.New("stale").Parse("{{.Reporter.Nope}}")),
+			}}
+		}
+		mockGroupByStaleness = func(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{githubstructures.Issue{Url: "url-1"}}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{}
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos(repoNames)
+	})
+
+	It("enforces exclusive label groups", func() {
+		mockAddLabelParams := []interface{}{}
+		mockRemoveLabelParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		severityGroup := githubstructures.ExclusiveGroup{Prefix: "severity", Policy: githubstructures.ExclusiveGroupPolicyEnum.LATEST}
+		testedGroup := githubstructures.ExclusiveGroup{LabelNames: []string{"tested & fails", "tested & works"}, Policy: githubstructures.ExclusiveGroupPolicyEnum.FLAG}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{
+				OverdueLabelName:  "overdue",
+				ExclusiveGroups:   []githubstructures.ExclusiveGroup{severityGroup, testedGroup},
+				ConflictLabelName: "conflict",
+			}
+		}
+		mockResolveExclusiveGroup = func(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool) {
+			if issue.Url == "url-1" && group.Prefix == "severity" {
+				return []string{"severity: minor", "severity: trivial"}, false
+			}
+			if issue.Url == "url-2" && group.Prefix == "" {
+				return []string{}, true
+			}
+			if issue.Url == "url-3" && group.Prefix == "" {
+				return []string{}, true
+			}
+			return []string{}, false
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "severity: major"}, githubstructures.Label{Name: "severity: minor"}, githubstructures.Label{Name: "severity: trivial"}}},
+				githubstructures.Issue{Url: "url-2"},
+				githubstructures.Issue{Url: "url-3", Labels: []githubstructures.Label{githubstructures.Label{Name: "conflict"}}},
+				githubstructures.Issue{Url: "url-4", Labels: []githubstructures.Label{githubstructures.Label{Name: "conflict"}}},
+			}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockAddLabelParams = append(mockAddLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRemoveLabelParams = append(mockRemoveLabelParams, []interface{}{issueUrl, labelName})
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		githubOperator.UpdateRepos(repoNames)
+
+		Expect(mockRemoveLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "severity: minor"},
+			[]interface{}{"url-1", "severity: trivial"},
+			[]interface{}{"url-4", "conflict"},
+		}))
+		Expect(mockAddLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-2", "conflict"},
+		}))
+	})
+
+	It("removes or flags orphaned labels", func() {
+		mockAddLabelParams := []interface{}{}
+		mockRemoveLabelParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type"},
+			githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.REMOVE},
+			githubstructures.ManualLabelConfig{Prefix: "priority", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.FLAG},
+		}
+
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, []githubstructures.Issue{}
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{OverdueLabelName: "overdue", InvalidCombinationLabelName: "invalid"}
+		}
+		mockFindOrphanedLabels = func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string {
+			if issue.Url == "url-1" && config.Prefix == "severity" {
+				return []string{"severity: minor"}
+			}
+			if issue.Url == "url-4" && config.Prefix == "severity" {
+				return []string{"severity: major"}
+			}
+			if (issue.Url == "url-2" || issue.Url == "url-3") && config.Prefix == "priority" {
+				return []string{"priority: high"}
+			}
+			return []string{}
+		}
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return answeringLabels
+		}
+		mockCreateLabel = func(repoName string, label githubstructures.Label) {
+		}
+		findIssuesCount := 0
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			findIssuesCount++
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "severity: minor"}}},
+				githubstructures.Issue{Url: "url-2"},
+				githubstructures.Issue{Url: "url-3", Labels: []githubstructures.Label{githubstructures.Label{Name: "invalid"}}},
+				githubstructures.Issue{Url: "url-4", Labels: []githubstructures.Label{githubstructures.Label{Name: "invalid"}}},
+			}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockAddLabelParams = append(mockAddLabelParams, []interface{}{issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRemoveLabelParams = append(mockRemoveLabelParams, []interface{}{issueUrl, labelName})
+		}
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		summary := githubOperator.UpdateRepos(repoNames)
+
+		Expect(findIssuesCount).To(Equal(1))
+		Expect(mockRemoveLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-1", "severity: minor"},
+			[]interface{}{"url-4", "invalid"},
+		}))
+		Expect(mockAddLabelParams).To(Equal([]interface{}{
+			[]interface{}{"url-2", "invalid"},
+		}))
+		Expect(summary).To(Equal(githubstructures.RunSummary{Repos: []githubstructures.RepoSummary{
+			githubstructures.RepoSummary{
+				RepoName:              "repo-1",
+				OrphanedLabelRemovals: []githubstructures.LabelChange{githubstructures.LabelChange{IssueUrl: "url-1", LabelName: "severity: minor"}},
+				Report: githubstructures.RepoReport{
+					RepoName:        "repo-1",
+					OpenIssuesCount: 4,
+					MissingLabelCounts: []githubstructures.MissingLabelCount{
+						githubstructures.MissingLabelCount{Prefix: "type", Count: 0},
+						githubstructures.MissingLabelCount{Prefix: "severity", Count: 0},
+						githubstructures.MissingLabelCount{Prefix: "priority", Count: 0},
+					},
+				},
+				LabelsCreatedCount:     3,
+				IssueLabelChangesCount: 3,
+			},
+		}}))
+	})
+
+	It("renames labels", func() {
+		mockRenameLabelParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+			"repo-2",
+			"repo-3",
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours", Color: "color-1"},
+			githubstructures.Label{Name: "answered", Color: "color-2"},
+			githubstructures.Label{Name: "not-answered", Color: "color-3"},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			switch repoName {
+			case "repo-2":
+				return []githubstructures.Label{githubstructures.Label{Name: "new-1", Color: "color-1"}}
+			case "repo-3":
+				return []githubstructures.Label{githubstructures.Label{Name: "old-1", Color: "color-1"}, githubstructures.Label{Name: "new-1", Color: "color-1"}}
+			}
+			return []githubstructures.Label{githubstructures.Label{Name: "old-1", Color: "color-1"}}
+		}
+		mockRenameLabel = func(repoName string, oldLabelName string, newLabelName string) {
+			mockRenameLabelParams = append(mockRenameLabelParams, []interface{}{"rename", repoName, oldLabelName, newLabelName})
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			return []githubstructures.Issue{githubstructures.Issue{Url: "url-1"}}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockRenameLabelParams = append(mockRenameLabelParams, []interface{}{"add", issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockRenameLabelParams = append(mockRenameLabelParams, []interface{}{"remove", issueUrl, labelName})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockRenameLabelParams = append(mockRenameLabelParams, []interface{}{"delete", repoName, labelName})
+		}
+		githubOperator.RecordSteps(func(step githubstructures.LabelStep) {
+			mockRenameLabelParams = append(mockRenameLabelParams, []interface{}{"step", step.Kind, step.RepoName})
+		})
+
+		githubOperator.RenameLabelInEachRepo(repoNames, "old-1", "new-1")
+
+		Expect(mockRenameLabelParams).To(Equal([]interface{}{
+			[]interface{}{"rename", "repo-1", "old-1", "new-1"},
+			[]interface{}{"step", githubstructures.LabelStepKindEnum.RENAME_LABEL, "repo-1"},
+			[]interface{}{"add", "url-1", "new-1"},
+			[]interface{}{"step", githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, "repo-3"},
+			[]interface{}{"remove", "url-1", "old-1"},
+			[]interface{}{"step", githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, "repo-3"},
+			[]interface{}{"delete", "repo-3", "old-1"},
+			[]interface{}{"step", githubstructures.LabelStepKindEnum.DELETE_LABEL, "repo-3"},
+		}))
+	})
+
+	It("merges labels", func() {
+		mockCallsParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+			"repo-2",
+			"repo-3",
+			"repo-4",
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			switch repoName {
+			case "repo-1":
+				return []githubstructures.Label{githubstructures.Label{Name: "bug"}, githubstructures.Label{Name: "type: bug"}}
+			case "repo-2":
+				return []githubstructures.Label{githubstructures.Label{Name: "bug"}}
+			case "repo-3":
+				return []githubstructures.Label{githubstructures.Label{Name: "type: bug"}}
+			case "repo-5":
+				return []githubstructures.Label{githubstructures.Label{Name: "type: bug"}, githubstructures.Label{Name: "Type:Bug"}}
+			}
+			return []githubstructures.Label{githubstructures.Label{Name: "Type: Bug"}}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			if repoName == "repo-5" {
+				return []githubstructures.Issue{githubstructures.Issue{Url: "url-3", Labels: []githubstructures.Label{githubstructures.Label{Name: "Type:Bug"}}}}
+			}
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1"},
+				githubstructures.Issue{Url: "url-2", IsPullRequest: true, Labels: []githubstructures.Label{githubstructures.Label{Name: "Type: Bug"}}},
+			}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"add", issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"remove", issueUrl, labelName})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"delete", repoName, labelName})
+		}
+		mockRenameLabel = func(repoName string, oldLabelName string, newLabelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"rename", repoName, oldLabelName, newLabelName})
+		}
+
+		steps := githubOperator.MergeLabelInEachRepo(repoNames, "bug", "type: bug")
+		githubOperator.MergeLabelInEachRepo([]string{"repo-4"}, "Type: Bug", "type: bug")
+		githubOperator.MergeLabelInEachRepo([]string{"repo-5"}, "Type:Bug", "type: bug")
+
+		Expect(steps).To(Equal([]githubstructures.LabelStep{
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-1", LabelName: "type: bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-1", LabelName: "bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-2", LabelName: "bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "bug", Before: githubstructures.Label{Name: "bug"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-2", LabelName: "bug", NewLabelName: "type: bug"},
+		}))
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"add", "url-1", "type: bug"},
+			[]interface{}{"remove", "url-1", "bug"},
+			[]interface{}{"remove", "url-2", "bug"},
+			[]interface{}{"delete", "repo-1", "bug"},
+			[]interface{}{"rename", "repo-2", "bug", "type: bug"},
+			[]interface{}{"rename", "repo-4", "Type: Bug", "type: bug"},
+			[]interface{}{"add", "url-3", "type: bug"},
+			[]interface{}{"remove", "url-3", "Type:Bug"},
+			[]interface{}{"delete", "repo-5", "Type:Bug"},
+		}))
+	})
+
+	It("recolors labels", func() {
+		mockUpdateLabelParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+			"repo-2",
+			"repo-3",
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			switch repoName {
+			case "repo-1":
+				return []githubstructures.Label{githubstructures.Label{Name: "Bug", Color: "ededed", Description: "description-1"}}
+			case "repo-2":
+				return []githubstructures.Label{githubstructures.Label{Name: "bug", Color: "D73A4A"}}
+			}
+			return []githubstructures.Label{}
+		}
+		mockUpdateLabel = func(repoName string, labelName string, label githubstructures.Label) {
+			mockUpdateLabelParams = append(mockUpdateLabelParams, []interface{}{repoName, labelName, label})
+		}
+
+		steps := githubOperator.RecolorLabelInEachRepo(repoNames, "bug", "d73a4a")
+
+		Expect(steps).To(Equal([]githubstructures.LabelStep{
+			githubstructures.LabelStep{
+				Kind:      githubstructures.LabelStepKindEnum.UPDATE_LABEL,
+				RepoName:  "repo-1",
+				LabelName: "Bug",
+				Before:    githubstructures.Label{Name: "Bug", Color: "ededed", Description: "description-1"},
+				After:     githubstructures.Label{Name: "Bug", Color: "d73a4a", Description: "description-1"},
+			},
+		}))
+		Expect(mockUpdateLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "Bug", githubstructures.Label{Name: "Bug", Color: "d73a4a", Description: "description-1"}},
+		}))
+	})
+
+	It("deletes unused labels", func() {
+		mockDeleteLabelParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+			"repo-2",
+			"repo-3",
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			if repoName == "repo-3" {
+				return []githubstructures.Label{}
+			}
+			return []githubstructures.Label{githubstructures.Label{Name: "wontfix"}}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			if repoName == "repo-2" {
+				return []githubstructures.Issue{githubstructures.Issue{Url: "url-1"}}
+			}
+			return []githubstructures.Issue{}
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockDeleteLabelParams = append(mockDeleteLabelParams, []interface{}{repoName, labelName})
+		}
+
+		githubOperator.DeleteUnusedLabelInEachRepo(repoNames, "wontfix")
+
+		Expect(mockDeleteLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "wontfix"},
+		}))
+	})
+
+	It("finds drift since the recorded steps", func() {
+		mockFindIssuesByLabelParams := []interface{}{}
+		steps := []githubstructures.LabelStep{
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-1", LabelName: "bug", NewLabelName: "type: bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-1", LabelName: "Foo", NewLabelName: "foo"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-1", LabelName: "enhancement", NewLabelName: "type: enhancement"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.UPDATE_LABEL, RepoName: "repo-1", LabelName: "wontfix", Before: githubstructures.Label{Name: "wontfix", Color: "aaaaaa"}, After: githubstructures.Label{Name: "wontfix", Color: "bbbbbb"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.UPDATE_LABEL, RepoName: "repo-1", LabelName: "duplicate", Before: githubstructures.Label{Name: "duplicate", Color: "aaaaaa"}, After: githubstructures.Label{Name: "duplicate", Color: "bbbbbb"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-1", LabelName: "type: question"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-1", LabelName: "triage"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-2", LabelName: "type: question"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-3", LabelName: "question"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-3", LabelName: "blocked"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-3", LabelName: "blocked"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "triage", Before: githubstructures.Label{Name: "triage"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-1", LabelName: "help", NewLabelName: "help wanted"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "help wanted", Before: githubstructures.Label{Name: "help wanted"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.CREATE_LABEL, RepoName: "repo-1", LabelName: "restored", After: githubstructures.Label{Name: "restored", Color: "dddddd"}},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return []githubstructures.Label{
+				githubstructures.Label{Name: "bug"},
+				githubstructures.Label{Name: "type: bug"},
+				githubstructures.Label{Name: "foo"},
+				githubstructures.Label{Name: "wontfix", Color: "cccccc"},
+				githubstructures.Label{Name: "duplicate", Color: "BBBBBB"},
+				githubstructures.Label{Name: "type: question"},
+				githubstructures.Label{Name: "question"},
+				githubstructures.Label{Name: "blocked"},
+			}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			mockFindIssuesByLabelParams = append(mockFindIssuesByLabelParams, []interface{}{repoName, labelName})
+			switch labelName {
+			case "type: question":
+				return []githubstructures.Issue{githubstructures.Issue{Url: "url-1"}}
+			case "question":
+				return []githubstructures.Issue{githubstructures.Issue{Url: "url-3"}}
+			}
+			return []githubstructures.Issue{}
+		}
+
+		drifts := githubOperator.FindDrift("repo-1", steps)
+
+		Expect(drifts).To(Equal([]string{
+			"repo-1: label \"bug\" exists again",
+			"repo-1: label \"type: enhancement\" is missing",
+			"repo-1: label \"wontfix\" has been changed",
+			"repo-1: label \"restored\" is missing",
+			"url-2: label \"type: question\" has been removed",
+			"url-3: label \"question\" has been added again",
+		}))
+		Expect(mockFindIssuesByLabelParams).To(Equal([]interface{}{
+			[]interface{}{"repo-1", "type: question"},
+			[]interface{}{"repo-1", "question"},
+			[]interface{}{"repo-1", "blocked"},
+		}))
+	})
+
+	It("reverts the recorded steps", func() {
+		mockCallsParams := []interface{}{}
+		mockRecordParams := []githubstructures.AuditEntry{}
+		steps := []githubstructures.LabelStep{
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.CREATE_LABEL, RepoName: "repo-1", LabelName: "restored", After: githubstructures.Label{Name: "restored", Color: "dddddd"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.RENAME_LABEL, RepoName: "repo-1", LabelName: "bug", NewLabelName: "type: bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.UPDATE_LABEL, RepoName: "repo-1", LabelName: "wontfix", Before: githubstructures.Label{Name: "wontfix", Color: "aaaaaa"}, After: githubstructures.Label{Name: "wontfix", Color: "bbbbbb"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "https://github.com/org/repo-1/issues/7", LabelName: "type: question"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "https://github.com/org/repo-1/issues/7", LabelName: "triage"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "triage", Before: githubstructures.Label{Name: "triage", Color: "cccccc"}},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockCreateLabel = func(repoName string, label githubstructures.Label) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"create", repoName, label})
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"add", issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"remove", issueUrl, labelName})
+		}
+		mockUpdateLabel = func(repoName string, labelName string, label githubstructures.Label) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"update", repoName, labelName, label})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"delete", repoName, labelName})
+		}
+		mockRenameLabel = func(repoName string, oldLabelName string, newLabelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"rename", repoName, oldLabelName, newLabelName})
+		}
+
+		mockRecord = func(entry githubstructures.AuditEntry) {
+			mockRecordParams = append(mockRecordParams, entry)
+		}
+
+		githubOperator.RevertLabelSteps("repo-1", steps)
+
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"create", "repo-1", githubstructures.Label{Name: "triage", Color: "cccccc"}},
+			[]interface{}{"add", "https://github.com/org/repo-1/issues/7", "triage"},
+			[]interface{}{"remove", "https://github.com/org/repo-1/issues/7", "type: question"},
+			[]interface{}{"update", "repo-1", "wontfix", githubstructures.Label{Name: "wontfix", Color: "aaaaaa"}},
+			[]interface{}{"rename", "repo-1", "type: bug", "bug"},
+			[]interface{}{"delete", "repo-1", "restored"},
+		}))
+		Expect(mockRecordParams).To(Equal([]githubstructures.AuditEntry{
+			githubstructures.AuditEntry{Repo: "repo-1", Operation: "CreateLabel", After: "triage #cccccc", Rule: "rollback"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "triage", Rule: "rollback"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "RemoveLabel", Before: "type: question", Rule: "rollback"},
+			githubstructures.AuditEntry{Repo: "repo-1", Operation: "UpdateLabel", Before: "wontfix #bbbbbb", After: "wontfix #aaaaaa", Rule: "rollback"},
+			githubstructures.AuditEntry{Repo: "repo-1", Operation: "RenameLabel", Before: "type: bug", After: "bug", Rule: "rollback"},
+			githubstructures.AuditEntry{Repo: "repo-1", Operation: "DeleteLabel", Before: "restored #dddddd", Rule: "rollback"},
+		}))
+	})
+
+	It("splits labels", func() {
+		mockCallsParams := []interface{}{}
+		repoNames := []string{
+			"repo-1",
+			"repo-2",
+			"repo-3",
+		}
+		rules := []githubstructures.LabelSplitRule{githubstructures.LabelSplitRule{LabelName: "type: bug"}}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			if repoName == "repo-3" {
+				return []githubstructures.Label{}
+			}
+			return []githubstructures.Label{githubstructures.Label{Name: "triage"}}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			if repoName == "repo-1" {
+				return []githubstructures.Issue{githubstructures.Issue{Url: "url-1"}, githubstructures.Issue{Url: "url-2"}}
+			}
+			return []githubstructures.Issue{githubstructures.Issue{Url: "url-3"}, githubstructures.Issue{Url: "url-4"}}
+		}
+		mockFindSplitLabel = func(issue githubstructures.Issue, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) string {
+			switch issue.Url {
+			case "url-1":
+				return "type: bug"
+			case "url-2":
+				return otherwiseLabelName
+			case "url-3":
+				return "type: bug"
+			}
+			return ""
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"add", issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"remove", issueUrl, labelName})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"delete", repoName, labelName})
+		}
+
+		githubOperator.SplitLabelInEachRepo(repoNames, "triage", rules, "type: question")
+
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"add", "url-1", "type: bug"},
+			[]interface{}{"remove", "url-1", "triage"},
+			[]interface{}{"add", "url-2", "type: question"},
+			[]interface{}{"remove", "url-2", "triage"},
+			[]interface{}{"delete", "repo-1", "triage"},
+			[]interface{}{"add", "url-3", "type: bug"},
+			[]interface{}{"remove", "url-3", "triage"},
+		}))
+	})
+
+	It("takes a snapshot of a repo", func() {
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			Expect(repoName).To(Equal("repo-1"))
+			return []githubstructures.Label{githubstructures.Label{Name: "type: bug", Color: "d73a4a", Description: "Something isn't working"}}
+		}
+		mockFindAllIssues = func(repoName string) []githubstructures.Issue {
+			Expect(repoName).To(Equal("repo-1"))
+			return []githubstructures.Issue{
+				githubstructures.Issue{Number: 1, Labels: []githubstructures.Label{githubstructures.Label{Name: "type: bug", Color: "d73a4a"}}},
+				githubstructures.Issue{Number: 2, IsPullRequest: true, Labels: []githubstructures.Label{}},
+			}
+		}
+
+		Expect(githubOperator.SnapshotRepo("repo-1")).To(Equal(githubstructures.RepoSnapshot{
+			RepoName: "repo-1",
+			Labels:   []githubstructures.Label{githubstructures.Label{Name: "type: bug", Color: "d73a4a", Description: "Something isn't working"}},
+			Issues: []githubstructures.IssueSnapshot{
+				githubstructures.IssueSnapshot{Number: 1, LabelNames: []string{"type: bug"}},
+				githubstructures.IssueSnapshot{Number: 2, IsPullRequest: true, LabelNames: []string{}},
+			},
+		}))
+	})
+
+	It("restores a repo from a snapshot", func() {
+		mockCallsParams := []interface{}{}
+		repoSnapshot := githubstructures.RepoSnapshot{
+			RepoName: "repo-1",
+			Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "type: bug", Color: "d73a4a"},
+				githubstructures.Label{Name: "blocked", Color: "000000"},
+			},
+			Issues: []githubstructures.IssueSnapshot{
+				githubstructures.IssueSnapshot{Number: 1, LabelNames: []string{"type: bug", "blocked"}},
+				githubstructures.IssueSnapshot{Number: 2, LabelNames: []string{"type: bug"}},
+				githubstructures.IssueSnapshot{Number: 3, LabelNames: []string{"blocked"}},
+			},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return []githubstructures.Label{githubstructures.Label{Name: "Type: Bug", Color: "d73a4a"}}
+		}
+		mockFindAllIssues = func(repoName string) []githubstructures.Issue {
+			return []githubstructures.Issue{
+				githubstructures.Issue{Url: "url-1", Number: 1, Labels: []githubstructures.Label{}},
+				githubstructures.Issue{Url: "url-2", Number: 2, Labels: []githubstructures.Label{githubstructures.Label{Name: "type: bug"}}},
+			}
+		}
+		mockCreateLabel = func(repoName string, label githubstructures.Label) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"create", repoName, label})
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"add", issueUrl, labelName})
+		}
+		expectedSteps := []githubstructures.LabelStep{
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.CREATE_LABEL, RepoName: "repo-1", LabelName: "blocked", After: githubstructures.Label{Name: "blocked", Color: "000000"}},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-1", LabelName: "type: bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.ADD_ISSUE_LABEL, RepoName: "repo-1", IssueUrl: "url-1", LabelName: "blocked"},
+		}
+
+		Expect(githubOperator.RestoreRepo(repoSnapshot, true)).To(Equal(expectedSteps))
+		Expect(mockCallsParams).To(Equal([]interface{}{}))
+
+		Expect(githubOperator.RestoreRepo(repoSnapshot, false)).To(Equal(expectedSteps))
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"create", "repo-1", githubstructures.Label{Name: "blocked", Color: "000000"}},
+			[]interface{}{"add", "url-1", "type: bug"},
+			[]interface{}{"add", "url-1", "blocked"},
+		}))
+	})
+
+	It("applies labels in place without renaming them", func() {
+		mockCallsParams := []interface{}{}
+		labels := []githubstructures.Label{
+			githubstructures.Label{Name: "type: bug", Color: "d73a4a", Description: "Something isn't working"},
+			githubstructures.Label{Name: "blocked", Color: "000000"},
+			githubstructures.Label{Name: "stale", Color: "795548"},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return []githubstructures.Label{
+				githubstructures.Label{Name: "Type: Bug", Color: "d73a4a"},
+				githubstructures.Label{Name: "blocked", Color: "#000"},
+				githubstructures.Label{Name: "extra", Color: "ffffff"},
+			}
+		}
+		mockCreateLabel = func(repoName string, label githubstructures.Label) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"create", repoName, label})
+		}
+		mockUpdateLabel = func(repoName string, labelName string, label githubstructures.Label) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"update", repoName, labelName, label})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"delete", repoName, labelName})
+		}
+
+		steps := githubOperator.ApplyLabelsInEachRepo([]string{"repo-1"}, labels, false)
+		steps = append(steps, githubOperator.ApplyLabelsInEachRepo([]string{"repo-2"}, labels, true)...)
+
+		updatedLabel := githubstructures.Label{Name: "Type: Bug", Color: "d73a4a", Description: "Something isn't working"}
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"update", "repo-1", "Type: Bug", updatedLabel},
+			[]interface{}{"create", "repo-1", labels[2]},
+			[]interface{}{"update", "repo-2", "Type: Bug", updatedLabel},
+			[]interface{}{"create", "repo-2", labels[2]},
+			[]interface{}{"delete", "repo-2", "extra"},
+		}))
+		Expect(steps).To(HaveLen(5))
+		Expect(steps[0]).To(Equal(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.UPDATE_LABEL, RepoName: "repo-1", LabelName: "Type: Bug", Before: githubstructures.Label{Name: "Type: Bug", Color: "d73a4a"}, After: updatedLabel}))
+		Expect(steps[4]).To(Equal(githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-2", LabelName: "extra", Before: githubstructures.Label{Name: "extra", Color: "ffffff"}}))
+	})
+
+	It("only changes the given issues", func() {
+		mockCallsParams := []interface{}{}
+		mockRecordParams := []githubstructures.AuditEntry{}
+		issues := []githubstructures.Issue{
+			githubstructures.Issue{Number: 1, Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "bug"}}},
+			githubstructures.Issue{Number: 2, Url: "url-2", Labels: []githubstructures.Label{githubstructures.Label{Name: "bug"}}},
+			githubstructures.Issue{Number: 3, Url: "url-3", Labels: []githubstructures.Label{githubstructures.Label{Name: "bug"}}},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher).ForIssues([]int{2, 3})
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			if repoName == "repo-2" {
+				return []githubstructures.Label{githubstructures.Label{Name: "bug"}}
+			}
+			return []githubstructures.Label{githubstructures.Label{Name: "bug"}, githubstructures.Label{Name: "type: bug"}, githubstructures.Label{Name: "extra"}}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			return issues[:2]
+		}
+		mockFindAllIssues = func(repoName string) []githubstructures.Issue {
+			return issues
+		}
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			return issues[:1]
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"add", issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"remove", issueUrl, labelName})
+		}
+		mockRecord = func(entry githubstructures.AuditEntry) {
+			mockRecordParams = append(mockRecordParams, entry)
+		}
+
+		githubOperator.MergeLabelInEachRepo([]string{"repo-1", "repo-2"}, "bug", "type: bug")
+		githubOperator.ApplyLabelsInEachRepo([]string{"repo-1"}, []githubstructures.Label{githubstructures.Label{Name: "type: bug", Color: "d73a4a"}, githubstructures.Label{Name: "stale"}}, true)
+
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"add", "url-2", "type: bug"},
+			[]interface{}{"remove", "url-2", "bug"},
+		}))
+		Expect(mockRecordParams).To(HaveLen(2))
+		Expect(githubOperator.SnapshotRepo("repo-1").Issues).To(Equal([]githubstructures.IssueSnapshot{
+			githubstructures.IssueSnapshot{Number: 2, LabelNames: []string{"bug"}},
+			githubstructures.IssueSnapshot{Number: 3, LabelNames: []string{"bug"}},
+		}))
+		Expect(githubOperator.githubclient.FindIssues("repo-1")).To(Equal([]githubstructures.Issue{}))
+	})
+
+	It("changes nothing on a dry run", func() {
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher).DryRun()
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return []githubstructures.Label{githubstructures.Label{Name: "bug"}, githubstructures.Label{Name: "type: bug"}}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			return []githubstructures.Issue{githubstructures.Issue{Number: 1, Url: "https://github.com/org/repo-1/issues/1", Labels: []githubstructures.Label{githubstructures.Label{Name: "bug"}}}}
+		}
+		mockRecord = func(entry githubstructures.AuditEntry) {
+			Fail("mockRecord called on a dry run")
+		}
+
+		steps := githubOperator.MergeLabelInEachRepo([]string{"repo-1"}, "bug", "type: bug")
+
+		Expect(steps).To(HaveLen(3))
+	})
+
+	It("reports the open issues of repos", func() {
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type"},
+			githubstructures.ManualLabelConfig{Prefix: "severity"},
+		}
+		issues := []githubstructures.Issue{
+			githubstructures.Issue{Number: 1},
+			githubstructures.Issue{Number: 2},
+			githubstructures.Issue{Number: 3},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindIssues = func(repoName string) []githubstructures.Issue {
+			if repoName == "repo-2" {
+				return []githubstructures.Issue{}
+			}
+			return issues
+		}
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			if len(issues) == 0 {
+				return []githubstructures.Issue{}, []githubstructures.Issue{}, []githubstructures.Issue{}
+			}
+			return issues[:1], []githubstructures.Issue{}, issues[1:]
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			if config.Prefix == "type" || len(issues) == 0 {
+				return issues, []githubstructures.Issue{}
+			}
+			return issues[:2], issues[2:]
+		}
+
+		Expect(githubOperator.ReportRepos([]string{"repo-1", "repo-2"})).To(Equal([]githubstructures.RepoReport{
+			githubstructures.RepoReport{RepoName: "repo-1", OpenIssuesCount: 3, OursCount: 1, AnsweredCount: 0, NotAnsweredCount: 2, MissingLabelCounts: []githubstructures.MissingLabelCount{
+				githubstructures.MissingLabelCount{Prefix: "type", Count: 0},
+				githubstructures.MissingLabelCount{Prefix: "severity", Count: 1},
+			}},
+			githubstructures.RepoReport{RepoName: "repo-2", OpenIssuesCount: 0, OursCount: 0, AnsweredCount: 0, NotAnsweredCount: 0, MissingLabelCounts: []githubstructures.MissingLabelCount{
+				githubstructures.MissingLabelCount{Prefix: "type", Count: 0},
+				githubstructures.MissingLabelCount{Prefix: "severity", Count: 0},
+			}},
+		}))
+	})
+
+	It("audits labels without changing them", func() {
+		defaultLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "blocked", Color: "000000"},
+			githubstructures.Label{Name: "severity: blocked", Color: "000000"},
+			githubstructures.Label{Name: "stale", Color: "795548", Description: "No activity"},
+			githubstructures.Label{Name: "overdue", Color: "b60205"},
+			githubstructures.Label{Name: "wip", Color: "a0a000"},
+		}
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type", MissingLabelColor: "fbca04"},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", defaultLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return []githubstructures.Label{
+				githubstructures.Label{Name: "Blocked", Color: "000000"},
+				githubstructures.Label{Name: "severity: blocked", Color: "000000"},
+				githubstructures.Label{Name: "stale", Color: "795548"},
+				githubstructures.Label{Name: "overdue", Color: "ff0000"},
+				githubstructures.Label{Name: "WIP", Color: "A0A000"},
+				githubstructures.Label{Name: "Bug", Color: "ee0701"},
+				githubstructures.Label{Name: "type: bug", Color: "d73a4a"},
+			}
+		}
+
+		Expect(githubOperator.AuditLabels([]string{"repo-1"})).To(Equal([]githubstructures.LabelAudit{
+			githubstructures.LabelAudit{
+				RepoName:      "repo-1",
+				MissingLabels: []githubstructures.Label{githubstructures.Label{Name: "missing type", Color: "fbca04", Description: "(managed by issue-overseer)"}},
+				MismatchedLabels: []githubstructures.LabelMismatch{
+					githubstructures.LabelMismatch{Expected: defaultLabels[2], Actual: githubstructures.Label{Name: "stale", Color: "795548"}},
+					githubstructures.LabelMismatch{Expected: defaultLabels[3], Actual: githubstructures.Label{Name: "overdue", Color: "ff0000"}},
+				},
+				ExtraLabels: []githubstructures.Label{
+					githubstructures.Label{Name: "Bug", Color: "ee0701"},
+					githubstructures.Label{Name: "type: bug", Color: "d73a4a"},
+				},
+				NearDuplicates: [][]string{[]string{"Bug", "type: bug"}},
+			},
+		}))
+	})
+
+	It("plans a label prune", func() {
+		defaultLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "type: bug", Color: "d73a4a"},
+			githubstructures.Label{Name: "blocked", Color: "000000"},
+			githubstructures.Label{Name: "severity: blocked", Color: "000000"},
+		}
+		taxonomyLabels := []githubstructures.Label{githubstructures.Label{Name: "help wanted", Color: "008672"}}
+		usage := func(labelName string, usesCount int) githubstructures.LabelUsage {
+			return githubstructures.LabelUsage{Label: githubstructures.Label{Name: labelName}, UsesCount: usesCount}
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", defaultLabels, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabelUsages = func(repoName string) []githubstructures.LabelUsage {
+			Expect(repoName).To(Equal("repo-1"))
+			return []githubstructures.LabelUsage{
+				usage("Bug", 3),
+				usage("type: bug", 5),
+				usage("Type:Bug", 0),
+				usage("wontfix", 0),
+				usage("help wanted", 0),
+				usage("blocked", 0),
+				usage("severity: blocked", 0),
+				usage("old", 2),
+				usage("docs", 0),
+				usage("type: docs", 1),
+			}
+		}
+
+		Expect(githubOperator.PlanLabelPrune([]string{"repo-1"}, taxonomyLabels)).To(Equal([]githubstructures.LabelPrunePlan{
+			githubstructures.LabelPrunePlan{
+				RepoName:       "repo-1",
+				LabelsToDelete: []githubstructures.LabelUsage{usage("wontfix", 0), usage("docs", 0)},
+				LabelsToMerge: []githubstructures.LabelMergeProposal{
+					githubstructures.LabelMergeProposal{Source: usage("Bug", 3), TargetLabelName: "type: bug"},
+					githubstructures.LabelMergeProposal{Source: usage("Type:Bug", 0), TargetLabelName: "type: bug"},
+				},
+			},
+		}))
+	})
+
+	It("applies a label prune plan", func() {
+		mockCallsParams := []interface{}{}
+		plans := []githubstructures.LabelPrunePlan{
+			githubstructures.LabelPrunePlan{
+				RepoName:       "repo-1",
+				LabelsToDelete: []githubstructures.LabelUsage{githubstructures.LabelUsage{Label: githubstructures.Label{Name: "wontfix"}}},
+				LabelsToMerge: []githubstructures.LabelMergeProposal{
+					githubstructures.LabelMergeProposal{Source: githubstructures.LabelUsage{Label: githubstructures.Label{Name: "Bug"}, UsesCount: 1}, TargetLabelName: "type: bug"},
+				},
+			},
+		}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, nil, "by-ours", "answered", "not-answered", nil, nil, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindLabels = func(repoName string) []githubstructures.Label {
+			return []githubstructures.Label{
+				githubstructures.Label{Name: "Bug", Color: "ee0701"},
+				githubstructures.Label{Name: "type: bug", Color: "d73a4a"},
+				githubstructures.Label{Name: "wontfix", Color: "ffffff"},
+			}
+		}
+		mockFindIssuesByLabel = func(repoName string, labelName string) []githubstructures.Issue {
+			if labelName == "Bug" {
+				return []githubstructures.Issue{githubstructures.Issue{Url: "url-1", Labels: []githubstructures.Label{githubstructures.Label{Name: "Bug"}}}}
+			}
+			return []githubstructures.Issue{}
+		}
+		mockAddLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"add", issueUrl, labelName})
+		}
+		mockRemoveLabel = func(issueUrl string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"remove", issueUrl, labelName})
+		}
+		mockDeleteLabel = func(repoName string, labelName string) {
+			mockCallsParams = append(mockCallsParams, []interface{}{"delete", repoName, labelName})
+		}
+
+		steps := githubOperator.ApplyLabelPrune(plans)
+
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"add", "url-1", "type: bug"},
+			[]interface{}{"remove", "url-1", "Bug"},
+			[]interface{}{"delete", "repo-1", "Bug"},
+			[]interface{}{"delete", "repo-1", "wontfix"},
+		}))
+		Expect(steps).To(HaveLen(4))
+	})
+
+	It("explains the decisions and planned changes for one issue", func() {
+		issue := githubstructures.Issue{
+			Url:    "https://github.com/org/repo-1/issues/7",
+			Number: 7,
+			Labels: []githubstructures.Label{githubstructures.Label{Name: "not-answered"}, githubstructures.Label{Name: "Stale"}, githubstructures.Label{Name: "Missing Severity"}},
+		}
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type"},
+			githubstructures.ManualLabelConfig{Prefix: "severity"},
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours"},
+			githubstructures.Label{Name: "answered"},
+			githubstructures.Label{Name: "not-answered"},
+		}
+		staleConfig := githubstructures.StaleConfig{After: 24 * time.Hour, LabelName: "stale"}
+		answeringDecision := githubstructures.TriageDecision{Type: githubstructures.IssueAnsweringTypeEnum.ANSWERED, Reason: "the last comment is by a member"}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindIssue = func(repoName string, number int) (githubstructures.Issue, bool) {
+			Expect(repoName).To(Equal("repo-1"))
+			return issue, number == 7
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{OverdueLabelName: "overdue", Stale: staleConfig}
+		}
+		mockTriageOneIssueByAnswering = func(issue githubstructures.Issue) githubstructures.TriageDecision {
+			return answeringDecision
+		}
+		mockTriageOneIssueByManualLabel = func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.TriageDecision {
+			return githubstructures.TriageDecision{Type: githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT, Reason: config.Prefix}
+		}
+		mockTriageOneIssueByStaleness = func(issue githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) githubstructures.TriageDecision {
+			Expect(config).To(Equal(staleConfig))
+			return githubstructures.TriageDecision{Type: githubstructures.IssueStaleTypeEnum.REVIVED}
+		}
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			Expect(issues).To(Equal([]githubstructures.Issue{issue}))
+			return []githubstructures.Issue{}, issues, []githubstructures.Issue{}
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			if config.Prefix == "type" {
+				return issues, []githubstructures.Issue{}
+			}
+			return []githubstructures.Issue{}, issues
+		}
+		mockGroupByStaleness = func(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			return []githubstructures.Issue{}, issues, []githubstructures.Issue{}
+		}
+		recordedEntries := []githubstructures.AuditEntry{}
+		mockRecord = func(entry githubstructures.AuditEntry) {
+			recordedEntries = append(recordedEntries, entry)
+		}
+
+		explanation, ok := githubOperator.ExplainIssue("repo-1", 7)
+
+		Expect(ok).To(BeTrue())
+		Expect(explanation).To(Equal(githubstructures.IssueExplanation{
+			RepoName: "repo-1",
+			Issue:    issue,
+			Decisions: []githubstructures.RuleDecision{
+				githubstructures.RuleDecision{Rule: "answering", Decision: answeringDecision},
+				githubstructures.RuleDecision{Rule: "missing labels: type", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT, Reason: "type"}},
+				githubstructures.RuleDecision{Rule: "missing labels: severity", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT, Reason: "severity"}},
+				githubstructures.RuleDecision{Rule: "stale", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueStaleTypeEnum.REVIVED}},
+			},
+			PlannedChanges: []githubstructures.AuditEntry{
+				githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "RemoveLabel", Before: "not-answered", Rule: "answering"},
+				githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "answered", Rule: "answering"},
+				githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "RemoveLabel", Before: "Stale", Rule: "stale"},
+			},
+		}))
+		Expect(recordedEntries).To(BeEmpty())
+
+		_, ok = githubOperator.ExplainIssue("repo-1", 8)
+
+		Expect(ok).To(BeFalse())
+	})
+
+	It("explains the exclusive group, orphaned label, overdue and escalation decisions", func() {
+		issues := map[int]githubstructures.Issue{
+			7: githubstructures.Issue{
+				Url:    "https://github.com/org/repo-1/issues/7",
+				Number: 7,
+				Labels: []githubstructures.Label{githubstructures.Label{Name: "not-answered"}, githubstructures.Label{Name: "severity: minor"}, githubstructures.Label{Name: "area: ui"}},
+			},
+			8: githubstructures.Issue{Url: "https://github.com/org/repo-1/issues/8", Number: 8, Labels: []githubstructures.Label{githubstructures.Label{Name: "answered"}}},
+			9: githubstructures.Issue{Url: "https://github.com/org/repo-1/issues/9", Number: 9},
+		}
+		manualLabelConfigs := []githubstructures.ManualLabelConfig{
+			githubstructures.ManualLabelConfig{Prefix: "type"},
+			githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.REMOVE},
+			githubstructures.ManualLabelConfig{Prefix: "area", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.REMOVE},
+			githubstructures.ManualLabelConfig{Prefix: "priority", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.FLAG},
+		}
+		answeringLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "by-ours"},
+			githubstructures.Label{Name: "answered"},
+			githubstructures.Label{Name: "not-answered"},
+		}
+		stages := []time.Duration{8 * time.Hour, 24 * time.Hour}
+
+		githubClient := Mockgithubclient{}
+		issuesTriage := Mockissuestriage{}
+		githubOperator := New(githubClient, issuesTriage, answeringLabels, "by-ours", "answered", "not-answered", answeringLabels, manualLabelConfigs, Mockrepoconfigs{}, Mockclock{}, Mockauditor{}, labelMatcher)
+
+		mockFindIssue = func(repoName string, number int) (githubstructures.Issue, bool) {
+			return issues[number], true
+		}
+		mockForRepo = func(repoName string) githubstructures.RepoConfig {
+			return githubstructures.RepoConfig{
+				Calendar:          githubstructures.CalendarConfig{WorkingDays: []time.Weekday{time.Monday, time.Friday}, WorkingHoursEnd: 24 * time.Hour},
+				SeverityDeadlines: map[string]githubstructures.SeverityDeadline{"critical": githubstructures.SeverityDeadline{Response: time.Hour}},
+				OverdueLabelName:  "overdue",
+				Escalation: githubstructures.EscalationConfig{
+					Mention:  "@on-call",
+					Template: template.Must(template.New("escalation").Parse("{{.Mention}} stage {{.Stage}}")),
+					Stages:   stages,
+				},
+				ExclusiveGroups: []githubstructures.ExclusiveGroup{
+					githubstructures.ExclusiveGroup{Prefix: "severity"},
+					githubstructures.ExclusiveGroup{LabelNames: []string{"wontfix", "duplicate"}},
+					githubstructures.ExclusiveGroup{Prefix: "priority"},
+				},
+				ConflictLabelName:           "conflict",
+				InvalidCombinationLabelName: "invalid",
+			}
+		}
+		mockNow = func() time.Time {
+			return time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+		}
+		mockTriageOneIssueByAnswering = func(issue githubstructures.Issue) githubstructures.TriageDecision {
+			if issue.Number == 8 {
+				return githubstructures.TriageDecision{Type: githubstructures.IssueAnsweringTypeEnum.ANSWERED}
+			}
+			return githubstructures.TriageDecision{Type: githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED}
+		}
+		mockTriageOneIssueByManualLabel = func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.TriageDecision {
+			return githubstructures.TriageDecision{Type: githubstructures.IssueManualLabelTypeEnum.EXISTENT}
+		}
+		mockResolveExclusiveGroup = func(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool) {
+			if issue.Number == 7 && group.Prefix == "severity" {
+				return []string{"severity: minor"}, false
+			}
+			return []string{}, issue.Number == 7 && group.Prefix == "priority"
+		}
+		mockFindOrphanedLabels = func(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string {
+			if issue.Number == 7 && config.Prefix == "area" {
+				return []string{"area: ui"}
+			}
+			if issue.Number == 7 && config.Prefix == "priority" {
+				return []string{"priority: high"}
+			}
+			return []string{}
+		}
+		mockGroupByDeadline = func(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue) {
+			if issues[0].Number == 7 {
+				return []githubstructures.DeadlineBreach{githubstructures.DeadlineBreach{Issue: issues[0], Severity: "critical", Kind: "response", MissedBy: 2 * time.Hour}}, []githubstructures.Issue{}
+			}
+			return []githubstructures.DeadlineBreach{}, issues
+		}
+		mockWaitingSince = func(issue githubstructures.Issue) time.Time {
+			return time.Date(2020, 7, 10, 10, 0, 0, 0, time.UTC)
+		}
+		mockTriageOneIssueByEscalation = func(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int {
+			if issue.Number == 7 {
+				return 2
+			}
+			return 0
+		}
+		mockEscalationMarker = func(stage int) string {
+			return "<marker>"
+		}
+		mockGroupByAnswering = func(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+			if issues[0].Number == 8 {
+				return []githubstructures.Issue{}, issues, []githubstructures.Issue{}
+			}
+			return []githubstructures.Issue{}, []githubstructures.Issue{}, issues
+		}
+		mockGroupByManualLabel = func(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
+			return issues, []githubstructures.Issue{}
+		}
+
+		explanation, _ := githubOperator.ExplainIssue("repo-1", 7)
+
+		Expect(explanation.Decisions[5:]).To(Equal([]githubstructures.RuleDecision{
+			githubstructures.RuleDecision{Rule: "exclusive groups: severity", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueExclusiveGroupTypeEnum.RESOLVED, Reason: "several labels of the group, removing severity: minor"}},
+			githubstructures.RuleDecision{Rule: "exclusive groups: wontfix, duplicate", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueExclusiveGroupTypeEnum.CONSISTENT, Reason: "at most one label of the group"}},
+			githubstructures.RuleDecision{Rule: "exclusive groups: priority", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueExclusiveGroupTypeEnum.CONFLICT, Reason: "several labels of the group, flagged as a conflict"}},
+			githubstructures.RuleDecision{Rule: "orphaned labels: severity", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueOrphanedLabelsTypeEnum.VALID, Reason: "no severity label without the type: bug label"}},
+			githubstructures.RuleDecision{Rule: "orphaned labels: area", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueOrphanedLabelsTypeEnum.REMOVED, Reason: "area: ui without the type: bug label, removing them"}},
+			githubstructures.RuleDecision{Rule: "orphaned labels: priority", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueOrphanedLabelsTypeEnum.FLAGGED, Reason: "priority: high without the type: bug label, flagged as invalid"}},
+			githubstructures.RuleDecision{Rule: "overdue", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueDeadlineTypeEnum.OVERDUE, Reason: "missed the critical response deadline by 2h0m0s"}},
+			githubstructures.RuleDecision{Rule: "escalation", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueEscalationTypeEnum.ESCALATED, Reason: "escalation stage 2 after waiting 24h0m0s"}},
+		}))
+		Expect(explanation.PlannedChanges).To(Equal([]githubstructures.AuditEntry{
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "RemoveLabel", Before: "severity: minor", Rule: "exclusive groups"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "conflict", Rule: "exclusive groups"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "RemoveLabel", Before: "area: ui", Rule: "orphaned labels"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "invalid", Rule: "orphaned labels"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "AddLabel", After: "overdue", Rule: "overdue"},
+			githubstructures.AuditEntry{Repo: "repo-1", Issue: 7, Operation: "CreateComment", After: "@on-call stage 2\n\n<marker>", Rule: "escalation"},
+		}))
+
+		explanation, _ = githubOperator.ExplainIssue("repo-1", 8)
+
+		Expect(explanation.Decisions[len(explanation.Decisions)-2:]).To(Equal([]githubstructures.RuleDecision{
+			githubstructures.RuleDecision{Rule: "overdue", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueDeadlineTypeEnum.ON_TIME, Reason: "no deadline missed"}},
+			githubstructures.RuleDecision{Rule: "escalation", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueEscalationTypeEnum.NOT_ESCALATED, Reason: "only issues waiting for an answer are escalated"}},
+		}))
+		Expect(explanation.PlannedChanges).To(BeEmpty())
+
+		explanation, _ = githubOperator.ExplainIssue("repo-1", 9)
+
+		Expect(explanation.Decisions[len(explanation.Decisions)-1]).To(Equal(githubstructures.RuleDecision{Rule: "escalation", Decision: githubstructures.TriageDecision{Type: githubstructures.IssueEscalationTypeEnum.NOT_ESCALATED, Reason: "no escalation due after waiting 24h0m0s"}}))
 	})
 })
@@ -1,5 +1,14 @@
 package githubstructures
 
+import (
+	"encoding/json"
+	"regexp"
+	"text/template"
+	"time"
+)
+
+const BOT_COMMENT_MARKER = "<!-- issue-overseer:"
+
 type issueAnsweringTypeEnum struct {
 	OURS         int
 	ANSWERED     int
@@ -22,14 +31,124 @@ var IssueManualLabelTypeEnum = &issueManualLabelTypeEnum{
 	NON_EXISTENT: 2,
 }
 
+type manualLabelDependencyModeEnum struct {
+	IGNORE int
+	REMOVE int
+	FLAG   int
+}
+
+var ManualLabelDependencyModeEnum = &manualLabelDependencyModeEnum{
+	IGNORE: 1,
+	REMOVE: 2,
+	FLAG:   3,
+}
+
+type issueStaleTypeEnum struct {
+	ACTIVE         int
+	BECOMING_STALE int
+	STALE          int
+	REVIVED        int
+	TO_CLOSE       int
+}
+
+var IssueStaleTypeEnum = &issueStaleTypeEnum{
+	ACTIVE:         1,
+	BECOMING_STALE: 2,
+	STALE:          3,
+	REVIVED:        4,
+	TO_CLOSE:       5,
+}
+
+type issueDeadlineTypeEnum struct {
+	ON_TIME int
+	OVERDUE int
+}
+
+var IssueDeadlineTypeEnum = &issueDeadlineTypeEnum{
+	ON_TIME: 1,
+	OVERDUE: 2,
+}
+
+type issueEscalationTypeEnum struct {
+	NOT_ESCALATED int
+	ESCALATED     int
+}
+
+var IssueEscalationTypeEnum = &issueEscalationTypeEnum{
+	NOT_ESCALATED: 1,
+	ESCALATED:     2,
+}
+
+type issueExclusiveGroupTypeEnum struct {
+	CONSISTENT int
+	RESOLVED   int
+	CONFLICT   int
+}
+
+var IssueExclusiveGroupTypeEnum = &issueExclusiveGroupTypeEnum{
+	CONSISTENT: 1,
+	RESOLVED:   2,
+	CONFLICT:   3,
+}
+
+type issueOrphanedLabelsTypeEnum struct {
+	VALID   int
+	REMOVED int
+	FLAGGED int
+}
+
+var IssueOrphanedLabelsTypeEnum = &issueOrphanedLabelsTypeEnum{
+	VALID:   1,
+	REMOVED: 2,
+	FLAGGED: 3,
+}
+
+type exclusiveGroupPolicyEnum struct {
+	LATEST   int
+	PRIORITY int
+	FLAG     int
+}
+
+var ExclusiveGroupPolicyEnum = &exclusiveGroupPolicyEnum{
+	LATEST:   1,
+	PRIORITY: 2,
+	FLAG:     3,
+}
+
+type labelStepKindEnum struct {
+	RENAME_LABEL       int
+	UPDATE_LABEL       int
+	DELETE_LABEL       int
+	ADD_ISSUE_LABEL    int
+	REMOVE_ISSUE_LABEL int
+	CREATE_LABEL       int
+}
+
+var LabelStepKindEnum = &labelStepKindEnum{
+	RENAME_LABEL:       1,
+	UPDATE_LABEL:       2,
+	DELETE_LABEL:       3,
+	ADD_ISSUE_LABEL:    4,
+	REMOVE_ISSUE_LABEL: 5,
+	CREATE_LABEL:       6,
+}
+
 type Label struct {
-	Name  string
-	Color string
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
 }
 
 type Comment struct {
 	AuthorAssociation string
 	AuthorLogin       string
+	Body              string
+	CreatedAt         time.Time
+}
+
+type LabelEvent struct {
+	LabelName string
+	CreatedAt time.Time
 }
 
 type Issue struct {
@@ -37,11 +156,232 @@ type Issue struct {
 	Url               string
 	Number            int
 	AuthorAssociation string
+	AuthorLogin       string
+	CreatedAt         time.Time
+	IsPullRequest     bool
 	Labels            []Label
+	LabelEvents       []LabelEvent
 	Comments          []Comment
 }
 
 type ManualLabelConfig struct {
-	Prefix          string
-	ParentLabelName string
+	Prefix                  string
+	ParentLabelName         string
+	DependencyMode          int
+	MissingLabelColor       string
+	MissingLabelDescription string
+}
+
+type LabelSplitRule struct {
+	LabelName     string
+	TitlePattern  *regexp.Regexp
+	HasLabelName  string
+	IsPullRequest *bool
+}
+
+type LabelStep struct {
+	Kind         int
+	RepoName     string
+	IssueUrl     string
+	LabelName    string
+	NewLabelName string
+	Before       Label
+	After        Label
+}
+
+type AuditEntry struct {
+	Repo      string
+	Issue     int
+	Operation string
+	Before    string
+	After     string
+	Rule      string
+}
+
+type IssueSnapshot struct {
+	Number        int      `json:"number"`
+	IsPullRequest bool     `json:"isPullRequest"`
+	LabelNames    []string `json:"labelNames"`
+}
+
+type RepoSnapshot struct {
+	RepoName string          `json:"repoName"`
+	Labels   []Label         `json:"labels"`
+	Issues   []IssueSnapshot `json:"issues"`
+}
+
+type LabelMismatch struct {
+	Expected Label `json:"expected"`
+	Actual   Label `json:"actual"`
+}
+
+type LabelAudit struct {
+	RepoName         string          `json:"repoName"`
+	MissingLabels    []Label         `json:"missingLabels"`
+	MismatchedLabels []LabelMismatch `json:"mismatchedLabels"`
+	ExtraLabels      []Label         `json:"extraLabels"`
+	NearDuplicates   [][]string      `json:"nearDuplicates"`
+}
+
+type TokenInfo struct {
+	Login             string
+	Scopes            []string
+	IsApp             bool
+	RepositoriesCount int
+}
+
+type MissingLabelCount struct {
+	Prefix string `json:"prefix"`
+	Count  int    `json:"count"`
+}
+
+type RepoReport struct {
+	RepoName           string              `json:"repoName"`
+	OpenIssuesCount    int                 `json:"openIssuesCount"`
+	OursCount          int                 `json:"oursCount"`
+	AnsweredCount      int                 `json:"answeredCount"`
+	NotAnsweredCount   int                 `json:"notAnsweredCount"`
+	MissingLabelCounts []MissingLabelCount `json:"missingLabelCounts"`
+}
+
+type LabelUsage struct {
+	Label     Label
+	UsesCount int
+}
+
+type LabelMergeProposal struct {
+	Source          LabelUsage
+	TargetLabelName string
+}
+
+type LabelPrunePlan struct {
+	RepoName       string
+	LabelsToDelete []LabelUsage
+	LabelsToMerge  []LabelMergeProposal
+}
+
+type SkippedComment struct {
+	Comment Comment
+	Reason  string
+}
+
+type TriageDecision struct {
+	Type            int
+	Reason          string
+	DecidingComment *Comment
+	SkippedComments []SkippedComment
+}
+
+type RuleDecision struct {
+	Rule     string
+	Decision TriageDecision
+}
+
+type IssueExplanation struct {
+	RepoName       string
+	Issue          Issue
+	Decisions      []RuleDecision
+	PlannedChanges []AuditEntry
+}
+
+type LabelChange struct {
+	IssueUrl  string `json:"issueUrl"`
+	LabelName string `json:"labelName"`
+}
+
+type RepoSummary struct {
+	RepoName               string        `json:"repoName"`
+	OrphanedLabelRemovals  []LabelChange `json:"orphanedLabelRemovals"`
+	Report                 RepoReport    `json:"report"`
+	LabelsCreatedCount     int           `json:"labelsCreatedCount"`
+	LabelsUpdatedCount     int           `json:"labelsUpdatedCount"`
+	LabelsDeletedCount     int           `json:"labelsDeletedCount"`
+	IssueLabelChangesCount int           `json:"issueLabelChangesCount"`
+	RequestsCount          int           `json:"requestsCount"`
+	ErrorsCount            int           `json:"errorsCount"`
+	Duration               time.Duration `json:"-"`
+}
+
+func (repoSummary RepoSummary) MarshalJSON() ([]byte, error) {
+	type repoSummaryJson RepoSummary
+	return json.Marshal(struct {
+		repoSummaryJson
+		Duration string `json:"duration"`
+	}{repoSummaryJson(repoSummary), repoSummary.Duration.Round(time.Millisecond).String()})
+}
+
+type RunSummary struct {
+	Repos []RepoSummary `json:"repos"`
+}
+
+type CalendarConfig struct {
+	Location          *time.Location
+	WorkingDays       []time.Weekday
+	WorkingHoursStart time.Duration
+	WorkingHoursEnd   time.Duration
+	Holidays          []time.Time
+}
+
+type Calendar interface {
+	BusinessDuration(from time.Time, to time.Time) time.Duration
+	AddBusinessDuration(from time.Time, duration time.Duration) time.Time
+}
+
+type SeverityDeadline struct {
+	Response   time.Duration
+	Resolution time.Duration
+}
+
+type DeadlineBreach struct {
+	Issue    Issue
+	Severity string
+	Kind     string
+	Deadline time.Time
+	MissedBy time.Duration
+}
+
+type EscalationConfig struct {
+	Mention  string
+	Template *template.Template
+	Stages   []time.Duration
+}
+
+type EscalationTemplateData struct {
+	Mention      string
+	Reporter     string
+	Title        string
+	Url          string
+	Age          string
+	WaitingHours int
+	Stage        int
+}
+
+type StaleConfig struct {
+	After      time.Duration
+	CloseAfter time.Duration
+	LabelName  string
+	Warning    *template.Template
+}
+
+type StaleTemplateData struct {
+	Reporter       string
+	Days           int
+	CloseAfterDays int
+}
+
+type ExclusiveGroup struct {
+	Prefix     string
+	LabelNames []string
+	Policy     int
+}
+
+type RepoConfig struct {
+	Calendar                    CalendarConfig
+	SeverityDeadlines           map[string]SeverityDeadline
+	OverdueLabelName            string
+	Escalation                  EscalationConfig
+	Stale                       StaleConfig
+	ExclusiveGroups             []ExclusiveGroup
+	ConflictLabelName           string
+	InvalidCombinationLabelName string
 }
@@ -5,5 +5,7 @@ go 1.14
 require (
 	github.com/onsi/ginkgo v1.13.0
 	github.com/onsi/gomega v1.10.1
+	golang.org/x/text v0.3.2
 	golang.org/x/tools v0.0.0-20200626171337-aa94e735be7f // indirect
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -2,55 +2,108 @@ package issuestriage
 
 import (
 	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
+type LabelMatcher interface {
+	Equal(labelName string, otherLabelName string) bool
+	HasPrefix(labelName string, prefix string) bool
+	Value(labelName string) string
+}
+
 type issuestriage struct {
+	labelMatcher LabelMatcher
 }
 
-func New() *issuestriage {
-	issuesTriage := &issuestriage{}
+func New(labelMatcher LabelMatcher) *issuestriage {
+	issuesTriage := &issuestriage{labelMatcher: labelMatcher}
 	return issuesTriage
 }
 
-func (issuesTriage issuestriage) TriageOneIssueByAnswering(issue githubstructures.Issue) int {
+func ignoreReason(comment githubstructures.Comment) string {
+	if comment.AuthorLogin == "issuehunt-app" {
+		return "posted by issuehunt-app"
+	}
+	if strings.Contains(comment.Body, githubstructures.BOT_COMMENT_MARKER) {
+		return "posted by issue-overseer"
+	}
+	return ""
+}
+
+func isIgnoredComment(comment githubstructures.Comment) bool {
+	return ignoreReason(comment) != ""
+}
+
+func skippedComments(comments []githubstructures.Comment) []githubstructures.SkippedComment {
+	skippedComments := []githubstructures.SkippedComment{}
+	for i := 0; i < len(comments); i++ {
+		reason := ignoreReason(comments[i])
+		if reason != "" {
+			skippedComments = append(skippedComments, githubstructures.SkippedComment{Comment: comments[i], Reason: reason})
+		}
+	}
+	return skippedComments
+}
+
+func skippedMemberComments(comments []githubstructures.Comment) []githubstructures.SkippedComment {
+	skippedComments := []githubstructures.SkippedComment{}
+	for i := 0; i < len(comments); i++ {
+		reason := ignoreReason(comments[i])
+		if reason == "" {
+			reason = "posted by a maintainer (" + comments[i].AuthorAssociation + ") on an issue reported by a maintainer"
+		}
+		skippedComments = append(skippedComments, githubstructures.SkippedComment{Comment: comments[i], Reason: reason})
+	}
+	return skippedComments
+}
+
+func (issuesTriage issuestriage) TriageOneIssueByAnswering(issue githubstructures.Issue) githubstructures.TriageDecision {
 	comments := issue.Comments
-	if issue.AuthorAssociation == "MEMBER" {
-		j := len(comments) - 1
-		lastAuthorAssociation := ""
-		for ; j >= 0; j-- {
-			comment := comments[j]
-			if comment.AuthorLogin != "issuehunt-app" && lastAuthorAssociation == "" {
-				lastAuthorAssociation = comment.AuthorAssociation
-			}
-			if comment.AuthorLogin != "issuehunt-app" && comment.AuthorAssociation != "MEMBER" {
-				break
-			}
+	decision := githubstructures.TriageDecision{SkippedComments: skippedComments(comments)}
+	j := len(comments) - 1
+	for ; j >= 0; j-- {
+		if !isIgnoredComment(comments[j]) {
+			break
 		}
-		if j == -1 {
-			return githubstructures.IssueAnsweringTypeEnum.OURS
+	}
+	if j == -1 {
+		if issue.AuthorAssociation == "MEMBER" {
+			decision.Type = githubstructures.IssueAnsweringTypeEnum.OURS
+			decision.Reason = "reported by a member and nobody has commented"
 		} else {
-			if lastAuthorAssociation == "MEMBER" {
-				return githubstructures.IssueAnsweringTypeEnum.ANSWERED
-			} else {
-				return githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED
-			}
+			decision.Type = githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED
+			decision.Reason = "reported by a non-member (" + issue.AuthorAssociation + ") and nobody has commented"
 		}
-	} else {
-		j := len(comments) - 1
-		for ; j >= 0; j-- {
-			if comments[j].AuthorLogin != "issuehunt-app" {
+		return decision
+	}
+	lastComment := comments[j]
+	decision.DecidingComment = &lastComment
+	if issue.AuthorAssociation == "MEMBER" {
+		k := j
+		for ; k >= 0; k-- {
+			if !isIgnoredComment(comments[k]) && comments[k].AuthorAssociation != "MEMBER" {
 				break
 			}
 		}
-		if j == -1 {
-			return githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED
-		} else if comments[j].AuthorAssociation == "MEMBER" {
-			return githubstructures.IssueAnsweringTypeEnum.ANSWERED
-		} else {
-			return githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED
+		if k == -1 {
+			decision.Type = githubstructures.IssueAnsweringTypeEnum.OURS
+			decision.Reason = "reported by a member and only members have commented"
+			decision.DecidingComment = nil
+			decision.SkippedComments = skippedMemberComments(comments)
+			return decision
 		}
 	}
+	if lastComment.AuthorAssociation == "MEMBER" {
+		decision.Type = githubstructures.IssueAnsweringTypeEnum.ANSWERED
+		decision.Reason = "the last comment is by a member"
+	} else {
+		decision.Type = githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED
+		decision.Reason = "the last comment is by a non-member (" + lastComment.AuthorAssociation + ")"
+	}
+	return decision
 }
 
 func (issuesTriage issuestriage) GroupByAnswering(issues []githubstructures.Issue) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
@@ -59,7 +112,7 @@ func (issuesTriage issuestriage) GroupByAnswering(issues []githubstructures.Issu
 	notAnsweredIssues := []githubstructures.Issue{}
 	for i := 0; i < len(issues); i++ {
 		issue := issues[i]
-		switch issueType := issuesTriage.TriageOneIssueByAnswering(issue); issueType {
+		switch issuesTriage.TriageOneIssueByAnswering(issue).Type {
 		case githubstructures.IssueAnsweringTypeEnum.OURS:
 			ourIssues = append(ourIssues, issue)
 		case githubstructures.IssueAnsweringTypeEnum.ANSWERED:
@@ -71,26 +124,51 @@ func (issuesTriage issuestriage) GroupByAnswering(issues []githubstructures.Issu
 	return ourIssues, answeredIssues, notAnsweredIssues
 }
 
-func (issuesTriage issuestriage) TriageOneIssueByManualLabel(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) int {
+func (issuesTriage issuestriage) TriageOneIssueByManualLabel(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) githubstructures.TriageDecision {
 	labels := issue.Labels
-	hasPrefix := false
+	prefixedLabelName := ""
 	parentMatches := false
 	for i := 0; i < len(labels); i++ {
 		label := labels[i]
-		if strings.HasPrefix(label.Name, config.Prefix+": ") {
-			hasPrefix = true
+		if prefixedLabelName == "" && issuesTriage.labelMatcher.HasPrefix(label.Name, config.Prefix) {
+			prefixedLabelName = label.Name
 		}
-		if label.Name == config.ParentLabelName {
+		if issuesTriage.labelMatcher.Equal(label.Name, config.ParentLabelName) {
 			parentMatches = true
 		}
 	}
-	if hasPrefix {
-		return githubstructures.IssueManualLabelTypeEnum.EXISTENT
+	if prefixedLabelName != "" {
+		return githubstructures.TriageDecision{
+			Type:   githubstructures.IssueManualLabelTypeEnum.EXISTENT,
+			Reason: "has the \"" + prefixedLabelName + "\" label",
+		}
 	}
 	if !parentMatches && config.ParentLabelName != "" {
-		return githubstructures.IssueManualLabelTypeEnum.EXISTENT
+		return githubstructures.TriageDecision{
+			Type:   githubstructures.IssueManualLabelTypeEnum.EXISTENT,
+			Reason: "doesn't need a \"" + config.Prefix + ": *\" label without the \"" + config.ParentLabelName + "\" label",
+		}
+	}
+	return githubstructures.TriageDecision{
+		Type:   githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT,
+		Reason: "has no \"" + config.Prefix + ": *\" label",
+	}
+}
+
+func (issuesTriage issuestriage) FindOrphanedLabels(issue githubstructures.Issue, config githubstructures.ManualLabelConfig) []string {
+	orphanedLabelNames := []string{}
+	if config.ParentLabelName == "" {
+		return orphanedLabelNames
 	}
-	return githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT
+	for i := 0; i < len(issue.Labels); i++ {
+		if issuesTriage.labelMatcher.Equal(issue.Labels[i].Name, config.ParentLabelName) {
+			return []string{}
+		}
+		if issuesTriage.labelMatcher.HasPrefix(issue.Labels[i].Name, config.Prefix) {
+			orphanedLabelNames = append(orphanedLabelNames, issue.Labels[i].Name)
+		}
+	}
+	return orphanedLabelNames
 }
 
 func (issuesTriage issuestriage) GroupByManualLabel(issues []githubstructures.Issue, config githubstructures.ManualLabelConfig) ([]githubstructures.Issue, []githubstructures.Issue) {
@@ -98,7 +176,7 @@ func (issuesTriage issuestriage) GroupByManualLabel(issues []githubstructures.Is
 	issuesWithoutLabel := []githubstructures.Issue{}
 	for i := 0; i < len(issues); i++ {
 		issue := issues[i]
-		switch issueType := issuesTriage.TriageOneIssueByManualLabel(issue, config); issueType {
+		switch issuesTriage.TriageOneIssueByManualLabel(issue, config).Type {
 		case githubstructures.IssueManualLabelTypeEnum.EXISTENT:
 			issuesWithLabel = append(issuesWithLabel, issue)
 		default:
@@ -107,3 +185,301 @@ func (issuesTriage issuestriage) GroupByManualLabel(issues []githubstructures.Is
 	}
 	return issuesWithLabel, issuesWithoutLabel
 }
+
+func (issuesTriage issuestriage) WaitingSince(issue githubstructures.Issue) time.Time {
+	waitingSince := time.Time{}
+	if issue.AuthorAssociation != "MEMBER" {
+		waitingSince = issue.CreatedAt
+	}
+	for i := 0; i < len(issue.Comments); i++ {
+		comment := issue.Comments[i]
+		if isIgnoredComment(comment) {
+			continue
+		}
+		if comment.AuthorAssociation == "MEMBER" {
+			waitingSince = time.Time{}
+		} else if waitingSince.IsZero() {
+			waitingSince = comment.CreatedAt
+		}
+	}
+	return waitingSince
+}
+
+func (issuesTriage issuestriage) findSeverity(issue githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline) string {
+	for i := 0; i < len(issue.Labels); i++ {
+		if !issuesTriage.labelMatcher.HasPrefix(issue.Labels[i].Name, "severity") {
+			continue
+		}
+		value := issuesTriage.labelMatcher.Value(issue.Labels[i].Name)
+		for severity := range deadlines {
+			if issuesTriage.labelMatcher.Equal(severity, value) {
+				return severity
+			}
+		}
+	}
+	return ""
+}
+
+func (issuesTriage issuestriage) TriageOneIssueByDeadline(issue githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) (githubstructures.DeadlineBreach, bool) {
+	severity := issuesTriage.findSeverity(issue, deadlines)
+	deadline, ok := deadlines[severity]
+	if severity == "" || !ok {
+		return githubstructures.DeadlineBreach{}, false
+	}
+	breach := githubstructures.DeadlineBreach{}
+	isBreached := false
+	waitingSince := issuesTriage.WaitingSince(issue)
+	if deadline.Response > 0 && !waitingSince.IsZero() {
+		elapsed := calendar.BusinessDuration(waitingSince, now)
+		if elapsed > deadline.Response {
+			breach = githubstructures.DeadlineBreach{
+				Issue:    issue,
+				Severity: severity,
+				Kind:     "response",
+				Deadline: calendar.AddBusinessDuration(waitingSince, deadline.Response),
+				MissedBy: elapsed - deadline.Response,
+			}
+			isBreached = true
+		}
+	}
+	if deadline.Resolution > 0 {
+		elapsed := calendar.BusinessDuration(issue.CreatedAt, now)
+		if elapsed > deadline.Resolution && elapsed-deadline.Resolution > breach.MissedBy {
+			breach = githubstructures.DeadlineBreach{
+				Issue:    issue,
+				Severity: severity,
+				Kind:     "resolution",
+				Deadline: calendar.AddBusinessDuration(issue.CreatedAt, deadline.Resolution),
+				MissedBy: elapsed - deadline.Resolution,
+			}
+			isBreached = true
+		}
+	}
+	return breach, isBreached
+}
+
+func (issuesTriage issuestriage) GroupByDeadline(issues []githubstructures.Issue, deadlines map[string]githubstructures.SeverityDeadline, calendar githubstructures.Calendar, now time.Time) ([]githubstructures.DeadlineBreach, []githubstructures.Issue) {
+	breaches := []githubstructures.DeadlineBreach{}
+	onTimeIssues := []githubstructures.Issue{}
+	for i := 0; i < len(issues); i++ {
+		issue := issues[i]
+		breach, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, calendar, now)
+		if isBreached {
+			breaches = append(breaches, breach)
+		} else {
+			onTimeIssues = append(onTimeIssues, issue)
+		}
+	}
+	sort.SliceStable(breaches, func(i, j int) bool {
+		return breaches[i].MissedBy > breaches[j].MissedBy
+	})
+	return breaches, onTimeIssues
+}
+
+func (issuesTriage issuestriage) EscalationMarker(stage int) string {
+	return githubstructures.BOT_COMMENT_MARKER + "escalation:" + strconv.Itoa(stage) + " -->"
+}
+
+func (issuesTriage issuestriage) TriageOneIssueByEscalation(issue githubstructures.Issue, stages []time.Duration, waitingTime time.Duration) int {
+	waitingSince := issuesTriage.WaitingSince(issue)
+	if waitingSince.IsZero() {
+		return 0
+	}
+	dueStage := 0
+	for i := 0; i < len(stages); i++ {
+		if waitingTime >= stages[i] {
+			dueStage = i + 1
+		}
+	}
+	for i := 0; i < len(issue.Comments); i++ {
+		comment := issue.Comments[i]
+		if comment.CreatedAt.Before(waitingSince) {
+			continue
+		}
+		for stage := dueStage; stage <= len(stages); stage++ {
+			if strings.Contains(comment.Body, issuesTriage.EscalationMarker(stage)) {
+				return 0
+			}
+		}
+	}
+	return dueStage
+}
+
+func (issuesTriage issuestriage) StaleMarker() string {
+	return githubstructures.BOT_COMMENT_MARKER + "stale -->"
+}
+
+func (issuesTriage issuestriage) TriageOneIssueByStaleness(issue githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) githubstructures.TriageDecision {
+	lastActivity := issue.CreatedAt
+	lastComment := (*githubstructures.Comment)(nil)
+	warning := (*githubstructures.Comment)(nil)
+	for i := 0; i < len(issue.Comments); i++ {
+		comment := issue.Comments[i]
+		if strings.Contains(comment.Body, issuesTriage.StaleMarker()) {
+			warning = &comment
+		} else if !isIgnoredComment(comment) {
+			lastActivity = comment.CreatedAt
+			lastComment = &comment
+			warning = nil
+		}
+	}
+	isLabelled := false
+	for i := 0; i < len(issue.Labels); i++ {
+		if issuesTriage.labelMatcher.Equal(issue.Labels[i].Name, config.LabelName) {
+			isLabelled = true
+		}
+	}
+	decision := githubstructures.TriageDecision{DecidingComment: lastComment, SkippedComments: skippedComments(issue.Comments)}
+	if issuesTriage.TriageOneIssueByAnswering(issue).Type != githubstructures.IssueAnsweringTypeEnum.ANSWERED {
+		if isLabelled {
+			decision.Type = githubstructures.IssueStaleTypeEnum.REVIVED
+			decision.Reason = "it is labelled as stale but is not answered"
+			return decision
+		}
+		decision.Type = githubstructures.IssueStaleTypeEnum.ACTIVE
+		decision.Reason = "only answered issues go stale"
+		return decision
+	}
+	if warning != nil {
+		decision.DecidingComment = warning
+		if config.CloseAfter > 0 && isLabelled && now.Sub(warning.CreatedAt) >= config.CloseAfter {
+			decision.Type = githubstructures.IssueStaleTypeEnum.TO_CLOSE
+			decision.Reason = "no activity for " + now.Sub(warning.CreatedAt).String() + " since the stale warning"
+			return decision
+		}
+		decision.Type = githubstructures.IssueStaleTypeEnum.STALE
+		decision.Reason = "already warned about staleness"
+		return decision
+	}
+	if isLabelled {
+		decision.Type = githubstructures.IssueStaleTypeEnum.REVIVED
+		decision.Reason = "there has been activity since the stale warning"
+		return decision
+	}
+	if now.Sub(lastActivity) >= config.After {
+		decision.Type = githubstructures.IssueStaleTypeEnum.BECOMING_STALE
+		decision.Reason = "no activity for " + now.Sub(lastActivity).String()
+		return decision
+	}
+	decision.Type = githubstructures.IssueStaleTypeEnum.ACTIVE
+	decision.Reason = "the last activity was " + now.Sub(lastActivity).String() + " ago"
+	return decision
+}
+
+func (issuesTriage issuestriage) GroupByStaleness(issues []githubstructures.Issue, config githubstructures.StaleConfig, now time.Time) ([]githubstructures.Issue, []githubstructures.Issue, []githubstructures.Issue) {
+	becomingStaleIssues := []githubstructures.Issue{}
+	revivedIssues := []githubstructures.Issue{}
+	issuesToClose := []githubstructures.Issue{}
+	for i := 0; i < len(issues); i++ {
+		issue := issues[i]
+		switch issuesTriage.TriageOneIssueByStaleness(issue, config, now).Type {
+		case githubstructures.IssueStaleTypeEnum.BECOMING_STALE:
+			becomingStaleIssues = append(becomingStaleIssues, issue)
+		case githubstructures.IssueStaleTypeEnum.REVIVED:
+			revivedIssues = append(revivedIssues, issue)
+		case githubstructures.IssueStaleTypeEnum.TO_CLOSE:
+			issuesToClose = append(issuesToClose, issue)
+		}
+	}
+	return becomingStaleIssues, revivedIssues, issuesToClose
+}
+
+func (issuesTriage issuestriage) isInExclusiveGroup(labelName string, group githubstructures.ExclusiveGroup) bool {
+	if issuesTriage.labelMatcher.HasPrefix(labelName, group.Prefix) {
+		return true
+	}
+	for i := 0; i < len(group.LabelNames); i++ {
+		if issuesTriage.labelMatcher.Equal(group.LabelNames[i], labelName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (issuesTriage issuestriage) findLatestLabel(issue githubstructures.Issue, labelNames []string) string {
+	latestLabelName := ""
+	latestAddedAt := time.Time{}
+	for i := 0; i < len(labelNames); i++ {
+		addedAt := time.Time{}
+		for j := 0; j < len(issue.LabelEvents); j++ {
+			labelEvent := issue.LabelEvents[j]
+			if issuesTriage.labelMatcher.Equal(labelEvent.LabelName, labelNames[i]) && labelEvent.CreatedAt.After(addedAt) {
+				addedAt = labelEvent.CreatedAt
+			}
+		}
+		if addedAt.After(latestAddedAt) {
+			latestLabelName = labelNames[i]
+			latestAddedAt = addedAt
+		} else if addedAt.Equal(latestAddedAt) {
+			latestLabelName = ""
+		}
+	}
+	return latestLabelName
+}
+
+func (issuesTriage issuestriage) findHighestPriorityLabel(labelNames []string, group githubstructures.ExclusiveGroup) string {
+	for i := 0; i < len(group.LabelNames); i++ {
+		for j := 0; j < len(labelNames); j++ {
+			if issuesTriage.labelMatcher.Equal(group.LabelNames[i], labelNames[j]) {
+				return labelNames[j]
+			}
+		}
+	}
+	return ""
+}
+
+func (issuesTriage issuestriage) ResolveExclusiveGroup(issue githubstructures.Issue, group githubstructures.ExclusiveGroup) ([]string, bool) {
+	labelNames := []string{}
+	for i := 0; i < len(issue.Labels); i++ {
+		if issuesTriage.isInExclusiveGroup(issue.Labels[i].Name, group) {
+			labelNames = append(labelNames, issue.Labels[i].Name)
+		}
+	}
+	if len(labelNames) < 2 {
+		return []string{}, false
+	}
+	labelNameToKeep := ""
+	switch group.Policy {
+	case githubstructures.ExclusiveGroupPolicyEnum.LATEST:
+		labelNameToKeep = issuesTriage.findLatestLabel(issue, labelNames)
+	case githubstructures.ExclusiveGroupPolicyEnum.PRIORITY:
+		labelNameToKeep = issuesTriage.findHighestPriorityLabel(labelNames, group)
+	}
+	if labelNameToKeep == "" {
+		return []string{}, true
+	}
+	labelNamesToRemove := []string{}
+	for i := 0; i < len(labelNames); i++ {
+		if labelNames[i] != labelNameToKeep {
+			labelNamesToRemove = append(labelNamesToRemove, labelNames[i])
+		}
+	}
+	return labelNamesToRemove, false
+}
+
+func (issuesTriage issuestriage) matchesSplitRule(issue githubstructures.Issue, rule githubstructures.LabelSplitRule) bool {
+	if rule.TitlePattern != nil && !rule.TitlePattern.MatchString(issue.Title) {
+		return false
+	}
+	if rule.IsPullRequest != nil && *rule.IsPullRequest != issue.IsPullRequest {
+		return false
+	}
+	if rule.HasLabelName == "" {
+		return true
+	}
+	for i := 0; i < len(issue.Labels); i++ {
+		if issuesTriage.labelMatcher.Equal(issue.Labels[i].Name, rule.HasLabelName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (issuesTriage issuestriage) FindSplitLabel(issue githubstructures.Issue, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) string {
+	for i := 0; i < len(rules); i++ {
+		if issuesTriage.matchesSplitRule(issue, rules[i]) {
+			return rules[i].LabelName
+		}
+	}
+	return otherwiseLabelName
+}
@@ -2,11 +2,14 @@ package issuestriage
 
 import (
 	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/labelmatch"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"log"
 	"os"
+	"regexp"
 	"testing"
+	"time"
 )
 
 func TestMain(m *testing.M) {
@@ -24,17 +27,29 @@ func TestMain(m *testing.M) {
 	}
 }
 
+type wallClockCalendar struct{}
+
+func (calendar wallClockCalendar) BusinessDuration(from time.Time, to time.Time) time.Duration {
+	return to.Sub(from)
+}
+
+func (calendar wallClockCalendar) AddBusinessDuration(from time.Time, duration time.Duration) time.Time {
+	return from.Add(duration)
+}
+
 func TestIssuesTriage(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "issuestriage")
 }
 
 var _ = Describe("issuestriage", func() {
+	labelMatcher := labelmatch.New(labelmatch.DefaultSeparators)
+
 	_ = Describe("GroupByAnswering", func() {
 		It("triages an empty list", func() {
 			issues := []githubstructures.Issue{}
 
-			issuesTriage := New()
+			issuesTriage := New(labelMatcher)
 			ourIssues, answeredIssues, notAnsweredIssues := issuesTriage.GroupByAnswering(issues)
 
 			Expect(ourIssues).To(Equal([]githubstructures.Issue{}))
@@ -61,7 +76,7 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Issue{Title: "title", Url: "url", Number: 127, AuthorAssociation: "NONE", Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{}},
 			}
 
-			issuesTriage := New()
+			issuesTriage := New(labelMatcher)
 			ourIssues, answeredIssues, notAnsweredIssues := issuesTriage.GroupByAnswering(issues)
 
 			Expect(ourIssues).To(Equal([]githubstructures.Issue{
@@ -92,8 +107,8 @@ var _ = Describe("issuestriage", func() {
 		It("returns OURS for an issue created by a member with no comments", func() {
 			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "MEMBER", Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.OURS))
 		})
@@ -103,8 +118,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.OURS))
 		})
@@ -114,8 +129,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -126,8 +141,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.ANSWERED))
 		})
@@ -138,8 +153,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -147,8 +162,8 @@ var _ = Describe("issuestriage", func() {
 		It("returns NOT_ANSWERED for an issue created by a non-member with no comments", func() {
 			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -158,8 +173,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.ANSWERED))
 		})
@@ -169,8 +184,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -181,8 +196,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.ANSWERED))
 		})
@@ -193,8 +208,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -204,8 +219,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "issuehunt-app"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.OURS))
 		})
@@ -215,8 +230,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "issuehunt-app"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -227,8 +242,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "issuehunt-app"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.ANSWERED))
 		})
@@ -239,8 +254,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "issuehunt-app"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -254,8 +269,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.ANSWERED))
 		})
@@ -270,8 +285,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -286,8 +301,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "issuehunt-app"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.ANSWERED))
 		})
@@ -303,8 +318,19 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "issuehunt-app"},
 			}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByAnswering(issue)
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
+
+			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
+		})
+
+		It("excludes our own bot comments", func() {
+			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "bot", Body: "ping <!-- issue-overseer:escalation:1 -->"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByAnswering(issue).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED))
 		})
@@ -314,8 +340,8 @@ var _ = Describe("issuestriage", func() {
 		It("returns NON_EXISTENT when there are no labels", func() {
 			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT))
 		})
@@ -325,8 +351,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "foo", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT))
 		})
@@ -338,8 +364,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "baz", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT))
 		})
@@ -351,8 +377,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "baz", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.EXISTENT))
 		})
@@ -362,8 +388,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "severity: major", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.EXISTENT))
 		})
@@ -374,8 +400,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "severity: major", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "bug"})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "bug"}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.EXISTENT))
 		})
@@ -386,8 +412,8 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "bug", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "bug"})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "bug"}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.EXISTENT))
 		})
@@ -397,19 +423,48 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "enhancement", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "bug"})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "bug"}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.EXISTENT))
 		})
 
+		It("returns EXISTENT for a differently cased prefix without a space", func() {
+			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "Severity:Major", Color: "000000"},
+				githubstructures.Label{Name: "Type - Bug", Color: "000000"},
+			}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+			decision := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug"})
+
+			Expect(decision).To(Equal(githubstructures.TriageDecision{
+				Type:   githubstructures.IssueManualLabelTypeEnum.EXISTENT,
+				Reason: "has the \"Severity:Major\" label",
+			}))
+		})
+
+		It("returns NON_EXISTENT when a differently cased parent matches", func() {
+			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "Type: Bug", Color: "000000"},
+			}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+			decision := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug"})
+
+			Expect(decision).To(Equal(githubstructures.TriageDecision{
+				Type:   githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT,
+				Reason: "has no \"severity: *\" label",
+			}))
+		})
+
 		It("returns NON_EXISTENT for exact name", func() {
 			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{
 				githubstructures.Label{Name: "severity", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT))
 		})
@@ -419,13 +474,53 @@ var _ = Describe("issuestriage", func() {
 				githubstructures.Label{Name: "severity-", Color: "000000"},
 			}, Comments: []githubstructures.Comment{}}
 
-			issuesTriage := New()
-			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
+			issuesTriage := New(labelMatcher)
+			issueType := issuesTriage.TriageOneIssueByManualLabel(issue, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""}).Type
 
 			Expect(issueType).To(Equal(githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT))
 		})
 	})
 
+	_ = Describe("FindOrphanedLabels", func() {
+		config := githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug", DependencyMode: githubstructures.ManualLabelDependencyModeEnum.REMOVE}
+
+		It("returns child labels when the parent label is missing", func() {
+			issue := githubstructures.Issue{Url: "url", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "type: enhancement", Color: "000000"},
+				githubstructures.Label{Name: "severity: major", Color: "000000"},
+				githubstructures.Label{Name: "severity: minor", Color: "000000"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelNames := issuesTriage.FindOrphanedLabels(issue, config)
+
+			Expect(labelNames).To(Equal([]string{"severity: major", "severity: minor"}))
+		})
+
+		It("returns nothing when the parent label is present", func() {
+			issue := githubstructures.Issue{Url: "url", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "severity: major", Color: "000000"},
+				githubstructures.Label{Name: "type: bug", Color: "000000"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelNames := issuesTriage.FindOrphanedLabels(issue, config)
+
+			Expect(labelNames).To(Equal([]string{}))
+		})
+
+		It("returns nothing when there is no parent label configured", func() {
+			issue := githubstructures.Issue{Url: "url", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "type: enhancement", Color: "000000"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelNames := issuesTriage.FindOrphanedLabels(issue, githubstructures.ManualLabelConfig{Prefix: "type"})
+
+			Expect(labelNames).To(Equal([]string{}))
+		})
+	})
+
 	_ = Describe("GroupByManualLabel", func() {
 		It("groups", func() {
 			issues := []githubstructures.Issue{
@@ -443,7 +538,7 @@ var _ = Describe("issuestriage", func() {
 				}, Comments: []githubstructures.Comment{}},
 			}
 
-			issuesTriage := New()
+			issuesTriage := New(labelMatcher)
 			issuesWithLabel, issuesWithoutLabel := issuesTriage.GroupByManualLabel(issues, githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: ""})
 
 			Expect(issuesWithLabel).To(Equal([]githubstructures.Issue{
@@ -464,4 +559,536 @@ var _ = Describe("issuestriage", func() {
 			}))
 		})
 	})
+
+	_ = Describe("WaitingSince", func() {
+		createdAt := time.Date(2020, 7, 10, 9, 0, 0, 0, time.UTC)
+		firstCommentAt := time.Date(2020, 7, 10, 10, 0, 0, 0, time.UTC)
+		secondCommentAt := time.Date(2020, 7, 10, 11, 0, 0, 0, time.UTC)
+		thirdCommentAt := time.Date(2020, 7, 10, 12, 0, 0, 0, time.UTC)
+
+		It("returns the creation time for an external issue with no comments", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.WaitingSince(issue)).To(Equal(createdAt))
+		})
+
+		It("returns zero for an issue created by a member with no comments", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "MEMBER", CreatedAt: createdAt, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.WaitingSince(issue)).To(Equal(time.Time{}))
+		})
+
+		It("returns zero when the last comment is by a member", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: firstCommentAt},
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: secondCommentAt},
+			}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.WaitingSince(issue)).To(Equal(time.Time{}))
+		})
+
+		It("returns the first external comment after the last member comment", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "MEMBER", CreatedAt: createdAt, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: firstCommentAt},
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: secondCommentAt},
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: thirdCommentAt},
+			}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.WaitingSince(issue)).To(Equal(secondCommentAt))
+		})
+
+		It("keeps the creation time when the reporter adds comments", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: firstCommentAt},
+			}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.WaitingSince(issue)).To(Equal(createdAt))
+		})
+
+		It("excludes an issuehunt-app comment", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "MEMBER", CreatedAt: createdAt, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "issuehunt-app", CreatedAt: firstCommentAt},
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: secondCommentAt},
+			}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.WaitingSince(issue)).To(Equal(secondCommentAt))
+		})
+	})
+
+	_ = Describe("TriageOneIssueByDeadline", func() {
+		createdAt := time.Date(2020, 7, 10, 9, 0, 0, 0, time.UTC)
+		now := time.Date(2020, 7, 10, 19, 0, 0, 0, time.UTC)
+		deadlines := map[string]githubstructures.SeverityDeadline{
+			"critical": githubstructures.SeverityDeadline{Response: 4 * time.Hour, Resolution: 8 * time.Hour},
+			"minor":    githubstructures.SeverityDeadline{Resolution: 20 * time.Hour},
+		}
+
+		It("returns no breach for an issue without severity", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+			_, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, wallClockCalendar{}, now)
+
+			Expect(isBreached).To(BeFalse())
+		})
+
+		It("returns no breach for a severity without deadlines", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "severity: trivial"},
+			}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+			_, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, wallClockCalendar{}, now)
+
+			Expect(isBreached).To(BeFalse())
+		})
+
+		It("returns no breach before the deadlines", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "severity: minor"},
+			}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+			_, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, wallClockCalendar{}, now)
+
+			Expect(isBreached).To(BeFalse())
+		})
+
+		It("returns a response breach", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "MEMBER", CreatedAt: time.Date(2020, 7, 10, 12, 0, 0, 0, time.UTC), Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "type: bug"},
+				githubstructures.Label{Name: "severity: critical"},
+			}, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: time.Date(2020, 7, 10, 14, 0, 0, 0, time.UTC)},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			breach, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, wallClockCalendar{}, now)
+
+			Expect(isBreached).To(BeTrue())
+			Expect(breach).To(Equal(githubstructures.DeadlineBreach{
+				Issue:    issue,
+				Severity: "critical",
+				Kind:     "response",
+				Deadline: time.Date(2020, 7, 10, 18, 0, 0, 0, time.UTC),
+				MissedBy: time.Hour,
+			}))
+		})
+
+		It("matches the severity label regardless of case and separator", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "Severity:Critical"},
+			}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+			breach, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, wallClockCalendar{}, now)
+
+			Expect(isBreached).To(BeTrue())
+			Expect(breach.Severity).To(Equal("critical"))
+		})
+
+		It("returns the resolution breach when it is missed by more", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "severity: critical"},
+			}, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: time.Date(2020, 7, 10, 10, 0, 0, 0, time.UTC)},
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: time.Date(2020, 7, 10, 14, 0, 0, 0, time.UTC)},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			breach, isBreached := issuesTriage.TriageOneIssueByDeadline(issue, deadlines, wallClockCalendar{}, now)
+
+			Expect(isBreached).To(BeTrue())
+			Expect(breach).To(Equal(githubstructures.DeadlineBreach{
+				Issue:    issue,
+				Severity: "critical",
+				Kind:     "resolution",
+				Deadline: time.Date(2020, 7, 10, 17, 0, 0, 0, time.UTC),
+				MissedBy: 2 * time.Hour,
+			}))
+		})
+	})
+
+	_ = Describe("GroupByDeadline", func() {
+		It("orders breaches by how much the deadline was missed", func() {
+			now := time.Date(2020, 7, 10, 19, 0, 0, 0, time.UTC)
+			deadlines := map[string]githubstructures.SeverityDeadline{
+				"critical": githubstructures.SeverityDeadline{Response: 4 * time.Hour},
+			}
+			issues := []githubstructures.Issue{
+				githubstructures.Issue{Number: 1, AuthorAssociation: "NONE", CreatedAt: time.Date(2020, 7, 10, 14, 0, 0, 0, time.UTC), Labels: []githubstructures.Label{
+					githubstructures.Label{Name: "severity: critical"},
+				}},
+				githubstructures.Issue{Number: 2, AuthorAssociation: "NONE", CreatedAt: time.Date(2020, 7, 10, 18, 0, 0, 0, time.UTC), Labels: []githubstructures.Label{
+					githubstructures.Label{Name: "severity: critical"},
+				}},
+				githubstructures.Issue{Number: 3, AuthorAssociation: "NONE", CreatedAt: time.Date(2020, 7, 10, 9, 0, 0, 0, time.UTC), Labels: []githubstructures.Label{
+					githubstructures.Label{Name: "severity: critical"},
+				}},
+			}
+
+			issuesTriage := New(labelMatcher)
+			breaches, onTimeIssues := issuesTriage.GroupByDeadline(issues, deadlines, wallClockCalendar{}, now)
+
+			Expect(breaches).To(Equal([]githubstructures.DeadlineBreach{
+				githubstructures.DeadlineBreach{Issue: issues[2], Severity: "critical", Kind: "response", Deadline: time.Date(2020, 7, 10, 13, 0, 0, 0, time.UTC), MissedBy: 6 * time.Hour},
+				githubstructures.DeadlineBreach{Issue: issues[0], Severity: "critical", Kind: "response", Deadline: time.Date(2020, 7, 10, 18, 0, 0, 0, time.UTC), MissedBy: time.Hour},
+			}))
+			Expect(onTimeIssues).To(Equal([]githubstructures.Issue{issues[1]}))
+		})
+	})
+
+	_ = Describe("TriageOneIssueByEscalation", func() {
+		createdAt := time.Date(2020, 7, 10, 9, 0, 0, 0, time.UTC)
+		stages := []time.Duration{8 * time.Hour, 24 * time.Hour}
+
+		It("returns the marker of a stage", func() {
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.EscalationMarker(2)).To(Equal("<!-- issue-overseer:escalation:2 -->"))
+		})
+
+		It("returns no stage for an issue which isn't waiting", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "MEMBER", CreatedAt: createdAt, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 30*time.Hour)).To(Equal(0))
+		})
+
+		It("returns no stage before the first threshold", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 7*time.Hour)).To(Equal(0))
+		})
+
+		It("returns the highest due stage", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 8*time.Hour)).To(Equal(1))
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 30*time.Hour)).To(Equal(2))
+		})
+
+		It("returns no stage when it has already been posted", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "bot", Body: "ping\n\n<!-- issue-overseer:escalation:1 -->", CreatedAt: createdAt.Add(time.Hour)},
+			}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 10*time.Hour)).To(Equal(0))
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 30*time.Hour)).To(Equal(2))
+		})
+
+		It("escalates again when the issue waits again after an answer", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Comments: []githubstructures.Comment{
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "bot", Body: "<!-- issue-overseer:escalation:1 -->", CreatedAt: createdAt.Add(time.Hour)},
+				githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: createdAt.Add(2 * time.Hour)},
+				githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "user", CreatedAt: createdAt.Add(3 * time.Hour)},
+			}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByEscalation(issue, stages, 10*time.Hour)).To(Equal(1))
+		})
+		It("explains the decision", func() {
+			escalation := githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "bot", Body: "ping <!-- issue-overseer:escalation:1 -->"}
+			answer := githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user"}
+			issueHunt := githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "issuehunt-app"}
+			issue := githubstructures.Issue{Title: "title", Url: "url", Number: 121, AuthorAssociation: "NONE", Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{escalation, answer, issueHunt}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByAnswering(issue)).To(Equal(githubstructures.TriageDecision{
+				Type:            githubstructures.IssueAnsweringTypeEnum.ANSWERED,
+				Reason:          "the last comment is by a member",
+				DecidingComment: &answer,
+				SkippedComments: []githubstructures.SkippedComment{
+					githubstructures.SkippedComment{Comment: escalation, Reason: "posted by issue-overseer"},
+					githubstructures.SkippedComment{Comment: issueHunt, Reason: "posted by issuehunt-app"},
+				},
+			}))
+			issue.AuthorAssociation = "MEMBER"
+			Expect(issuesTriage.TriageOneIssueByAnswering(issue)).To(Equal(githubstructures.TriageDecision{
+				Type:   githubstructures.IssueAnsweringTypeEnum.OURS,
+				Reason: "reported by a member and only members have commented",
+				SkippedComments: []githubstructures.SkippedComment{
+					githubstructures.SkippedComment{Comment: escalation, Reason: "posted by issue-overseer"},
+					githubstructures.SkippedComment{Comment: answer, Reason: "posted by a maintainer (MEMBER) on an issue reported by a maintainer"},
+					githubstructures.SkippedComment{Comment: issueHunt, Reason: "posted by issuehunt-app"},
+				},
+			}))
+			issue.AuthorAssociation = "NONE"
+			issue.Comments = []githubstructures.Comment{}
+			Expect(issuesTriage.TriageOneIssueByAnswering(issue)).To(Equal(githubstructures.TriageDecision{
+				Type:            githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED,
+				Reason:          "reported by a non-member (NONE) and nobody has commented",
+				SkippedComments: []githubstructures.SkippedComment{},
+			}))
+		})
+	})
+
+	_ = Describe("TriageOneIssueByStaleness", func() {
+		createdAt := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+		answeredAt := time.Date(2020, 6, 2, 9, 0, 0, 0, time.UTC)
+		warnedAt := time.Date(2020, 7, 2, 9, 0, 0, 0, time.UTC)
+		config := githubstructures.StaleConfig{After: 30 * 24 * time.Hour, CloseAfter: 7 * 24 * time.Hour, LabelName: "stale"}
+		answeredComments := []githubstructures.Comment{
+			githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: answeredAt},
+		}
+		warnedComments := append(append([]githubstructures.Comment{}, answeredComments...), githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "bot", Body: "no activity\n\n<!-- issue-overseer:stale -->", CreatedAt: warnedAt})
+		staleLabels := []githubstructures.Label{githubstructures.Label{Name: "stale"}}
+
+		It("returns the stale marker", func() {
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.StaleMarker()).To(Equal("<!-- issue-overseer:stale -->"))
+		})
+
+		It("returns ACTIVE for a recently answered issue", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{}, Comments: answeredComments}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 6, 20, 9, 0, 0, 0, time.UTC))).To(Equal(githubstructures.TriageDecision{
+				Type:            githubstructures.IssueStaleTypeEnum.ACTIVE,
+				Reason:          "the last activity was 432h0m0s ago",
+				DecidingComment: &answeredComments[0],
+				SkippedComments: []githubstructures.SkippedComment{},
+			}))
+		})
+
+		It("returns ACTIVE for an issue waiting for our answer", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{}}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 9, 1, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.ACTIVE))
+		})
+
+		It("returns BECOMING_STALE for an answered issue without activity", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{}, Comments: answeredComments}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 7, 2, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.BECOMING_STALE))
+		})
+
+		It("returns STALE for a warned issue", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: staleLabels, Comments: warnedComments}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 7, 8, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.STALE))
+		})
+
+		It("returns TO_CLOSE for a warned issue after the closing period", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: staleLabels, Comments: warnedComments}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 7, 9, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.TO_CLOSE))
+		})
+
+		It("doesn't close when closing is off or the label has been removed by a human", func() {
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: staleLabels, Comments: warnedComments}
+			unlabelledIssue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: []githubstructures.Label{}, Comments: warnedComments}
+			noClosingConfig := githubstructures.StaleConfig{After: 30 * 24 * time.Hour, LabelName: "stale"}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, noClosingConfig, time.Date(2020, 9, 1, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.STALE))
+			Expect(issuesTriage.TriageOneIssueByStaleness(unlabelledIssue, config, time.Date(2020, 9, 1, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.STALE))
+		})
+
+		It("returns REVIVED for a stale issue with a new member comment", func() {
+			comments := append(append([]githubstructures.Comment{}, warnedComments...), githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: warnedAt.Add(time.Hour)})
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: staleLabels, Comments: comments}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 7, 9, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.REVIVED))
+		})
+
+		It("returns REVIVED for a stale issue with a new reporter comment", func() {
+			comments := append(append([]githubstructures.Comment{}, warnedComments...), githubstructures.Comment{AuthorAssociation: "NONE", AuthorLogin: "reporter", CreatedAt: warnedAt.Add(time.Hour)})
+			issue := githubstructures.Issue{AuthorAssociation: "NONE", CreatedAt: createdAt, Labels: staleLabels, Comments: comments}
+
+			issuesTriage := New(labelMatcher)
+
+			Expect(issuesTriage.TriageOneIssueByStaleness(issue, config, time.Date(2020, 7, 9, 9, 0, 0, 0, time.UTC)).Type).To(Equal(githubstructures.IssueStaleTypeEnum.REVIVED))
+		})
+	})
+
+	_ = Describe("GroupByStaleness", func() {
+		It("groups", func() {
+			config := githubstructures.StaleConfig{After: 24 * time.Hour, CloseAfter: 24 * time.Hour, LabelName: "stale"}
+			now := time.Date(2020, 7, 10, 9, 0, 0, 0, time.UTC)
+			issues := []githubstructures.Issue{
+				githubstructures.Issue{Number: 1, AuthorAssociation: "NONE", CreatedAt: now.Add(-72 * time.Hour), Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{
+					githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: now.Add(-48 * time.Hour)},
+				}},
+				githubstructures.Issue{Number: 2, AuthorAssociation: "NONE", CreatedAt: now.Add(-72 * time.Hour), Labels: []githubstructures.Label{githubstructures.Label{Name: "stale"}}, Comments: []githubstructures.Comment{}},
+				githubstructures.Issue{Number: 3, AuthorAssociation: "NONE", CreatedAt: now.Add(-72 * time.Hour), Labels: []githubstructures.Label{githubstructures.Label{Name: "stale"}}, Comments: []githubstructures.Comment{
+					githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: now.Add(-60 * time.Hour)},
+					githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "bot", Body: "<!-- issue-overseer:stale -->", CreatedAt: now.Add(-36 * time.Hour)},
+				}},
+				githubstructures.Issue{Number: 4, AuthorAssociation: "NONE", CreatedAt: now.Add(-72 * time.Hour), Labels: []githubstructures.Label{}, Comments: []githubstructures.Comment{
+					githubstructures.Comment{AuthorAssociation: "MEMBER", AuthorLogin: "user", CreatedAt: now.Add(-time.Hour)},
+				}},
+			}
+
+			issuesTriage := New(labelMatcher)
+			becomingStaleIssues, revivedIssues, issuesToClose := issuesTriage.GroupByStaleness(issues, config, now)
+
+			Expect(becomingStaleIssues).To(Equal([]githubstructures.Issue{issues[0]}))
+			Expect(revivedIssues).To(Equal([]githubstructures.Issue{issues[1]}))
+			Expect(issuesToClose).To(Equal([]githubstructures.Issue{issues[2]}))
+		})
+	})
+
+	_ = Describe("ResolveExclusiveGroup", func() {
+		severityLabels := []githubstructures.Label{
+			githubstructures.Label{Name: "type: bug"},
+			githubstructures.Label{Name: "severity: major"},
+			githubstructures.Label{Name: "severity: minor"},
+		}
+
+		It("returns nothing for an issue with at most one label of the group", func() {
+			issue := githubstructures.Issue{Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "severity: major"},
+				githubstructures.Label{Name: "severity"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelNamesToRemove, isConflict := issuesTriage.ResolveExclusiveGroup(issue, githubstructures.ExclusiveGroup{Prefix: "severity", Policy: githubstructures.ExclusiveGroupPolicyEnum.FLAG})
+
+			Expect(labelNamesToRemove).To(Equal([]string{}))
+			Expect(isConflict).To(BeFalse())
+		})
+
+		It("flags a conflict", func() {
+			issue := githubstructures.Issue{Labels: severityLabels}
+
+			issuesTriage := New(labelMatcher)
+			labelNamesToRemove, isConflict := issuesTriage.ResolveExclusiveGroup(issue, githubstructures.ExclusiveGroup{Prefix: "severity", Policy: githubstructures.ExclusiveGroupPolicyEnum.FLAG})
+
+			Expect(labelNamesToRemove).To(Equal([]string{}))
+			Expect(isConflict).To(BeTrue())
+		})
+
+		It("keeps the most recently added label", func() {
+			issue := githubstructures.Issue{Labels: severityLabels, LabelEvents: []githubstructures.LabelEvent{
+				githubstructures.LabelEvent{LabelName: "severity: minor", CreatedAt: time.Date(2020, 7, 10, 9, 0, 0, 0, time.UTC)},
+				githubstructures.LabelEvent{LabelName: "severity: major", CreatedAt: time.Date(2020, 7, 11, 9, 0, 0, 0, time.UTC)},
+				githubstructures.LabelEvent{LabelName: "type: bug", CreatedAt: time.Date(2020, 7, 12, 9, 0, 0, 0, time.UTC)},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelNamesToRemove, isConflict := issuesTriage.ResolveExclusiveGroup(issue, githubstructures.ExclusiveGroup{Prefix: "severity", Policy: githubstructures.ExclusiveGroupPolicyEnum.LATEST})
+
+			Expect(labelNamesToRemove).To(Equal([]string{"severity: minor"}))
+			Expect(isConflict).To(BeFalse())
+		})
+
+		It("flags a conflict when the most recently added label is unknown", func() {
+			issue := githubstructures.Issue{Labels: severityLabels, LabelEvents: []githubstructures.LabelEvent{}}
+
+			issuesTriage := New(labelMatcher)
+			labelNamesToRemove, isConflict := issuesTriage.ResolveExclusiveGroup(issue, githubstructures.ExclusiveGroup{Prefix: "severity", Policy: githubstructures.ExclusiveGroupPolicyEnum.LATEST})
+
+			Expect(labelNamesToRemove).To(Equal([]string{}))
+			Expect(isConflict).To(BeTrue())
+		})
+
+		It("keeps the highest priority label", func() {
+			issue := githubstructures.Issue{Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "tested & works"},
+				githubstructures.Label{Name: "tested & fails"},
+			}}
+			group := githubstructures.ExclusiveGroup{LabelNames: []string{"tested & fails", "tested & works"}, Policy: githubstructures.ExclusiveGroupPolicyEnum.PRIORITY}
+
+			issuesTriage := New(labelMatcher)
+			labelNamesToRemove, isConflict := issuesTriage.ResolveExclusiveGroup(issue, group)
+
+			Expect(labelNamesToRemove).To(Equal([]string{"tested & works"}))
+			Expect(isConflict).To(BeFalse())
+		})
+
+		It("flags a conflict when no label of a prefix group has a priority", func() {
+			issue := githubstructures.Issue{Labels: severityLabels}
+			group := githubstructures.ExclusiveGroup{Prefix: "severity", LabelNames: []string{"severity: critical"}, Policy: githubstructures.ExclusiveGroupPolicyEnum.PRIORITY}
+
+			issuesTriage := New(labelMatcher)
+			labelNamesToRemove, isConflict := issuesTriage.ResolveExclusiveGroup(issue, group)
+
+			Expect(labelNamesToRemove).To(Equal([]string{}))
+			Expect(isConflict).To(BeTrue())
+		})
+	})
+
+	_ = Describe("FindSplitLabel", func() {
+		isPullRequest := true
+		rules := []githubstructures.LabelSplitRule{
+			githubstructures.LabelSplitRule{LabelName: "type: bug", TitlePattern: regexp.MustCompile("(?i)crash")},
+			githubstructures.LabelSplitRule{LabelName: "type: enhancement", IsPullRequest: &isPullRequest},
+			githubstructures.LabelSplitRule{LabelName: "type: question", HasLabelName: "needs discussion"},
+		}
+
+		It("returns the label of the first matching rule", func() {
+			issue := githubstructures.Issue{Title: "Crash on start", IsPullRequest: true}
+
+			issuesTriage := New(labelMatcher)
+			labelName := issuesTriage.FindSplitLabel(issue, rules, "other")
+
+			Expect(labelName).To(Equal("type: bug"))
+		})
+
+		It("matches pull requests", func() {
+			issue := githubstructures.Issue{Title: "Add a button", IsPullRequest: true}
+
+			issuesTriage := New(labelMatcher)
+			labelName := issuesTriage.FindSplitLabel(issue, rules, "other")
+
+			Expect(labelName).To(Equal("type: enhancement"))
+		})
+
+		It("matches labels", func() {
+			issue := githubstructures.Issue{Title: "Add a button", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "needs discussion"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelName := issuesTriage.FindSplitLabel(issue, rules, "other")
+
+			Expect(labelName).To(Equal("type: question"))
+		})
+
+		It("returns the fallback label when no rule matches", func() {
+			issue := githubstructures.Issue{Title: "Add a button", Labels: []githubstructures.Label{
+				githubstructures.Label{Name: "blocked"},
+			}}
+
+			issuesTriage := New(labelMatcher)
+			labelName := issuesTriage.FindSplitLabel(issue, rules, "other")
+
+			Expect(labelName).To(Equal("other"))
+		})
+	})
 })
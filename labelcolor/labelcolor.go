@@ -0,0 +1,39 @@
+package labelcolor
+
+import (
+	"errors"
+	"strings"
+)
+
+func isHex(text string) bool {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func Parse(color string) (string, error) {
+	normalized := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(color), "#"))
+	if len(normalized) == 3 && isHex(normalized) {
+		normalized = string([]byte{normalized[0], normalized[0], normalized[1], normalized[1], normalized[2], normalized[2]})
+	}
+	if len(normalized) != 6 || !isHex(normalized) {
+		return "", errors.New("invalid color \"" + color + "\", expected 6 or 3 hex digits like \"d73a4a\" or \"#fff\"")
+	}
+	return normalized, nil
+}
+
+func Normalize(color string) string {
+	normalized, err := Parse(color)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(color))
+	}
+	return normalized
+}
+
+func Same(color string, otherColor string) bool {
+	return Normalize(color) == Normalize(otherColor)
+}
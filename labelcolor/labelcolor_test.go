@@ -0,0 +1,53 @@
+package labelcolor
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestLabelcolor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "labelcolor")
+}
+
+var _ = Describe("labelcolor", func() {
+	DescribeTable("Parse accepts",
+		func(color string, expectedColor string) {
+			Expect(Parse(color)).To(Equal(expectedColor))
+		},
+		Entry("lowercase", "d73a4a", "d73a4a"),
+		Entry("uppercase", "D73A4A", "d73a4a"),
+		Entry("leading hash", "#d73a4a", "d73a4a"),
+		Entry("shorthand", "fa0", "ffaa00"),
+		Entry("shorthand with a hash", "#FA0", "ffaa00"),
+		Entry("surrounding spaces", " #000000 ", "000000"),
+	)
+
+	DescribeTable("Parse rejects",
+		func(color string) {
+			_, err := Parse(color)
+
+			Expect(err).To(MatchError("invalid color \"" + color + "\", expected 6 or 3 hex digits like \"d73a4a\" or \"#fff\""))
+		},
+		Entry("empty", ""),
+		Entry("only a hash", "#"),
+		Entry("two hashes", "##fff"),
+		Entry("non-hex digits", "ggg000"),
+		Entry("four digits", "ffff"),
+		Entry("too long", "d73a4a0"),
+		Entry("color name", "red"),
+	)
+
+	DescribeTable("Same",
+		func(color string, otherColor string, expectedSame bool) {
+			Expect(Same(color, otherColor)).To(Equal(expectedSame))
+		},
+		Entry("same color", "d73a4a", "d73a4a", true),
+		Entry("different case", "D73A4A", "d73a4a", true),
+		Entry("hash and shorthand", "#fa0", "FFAA00", true),
+		Entry("different colors", "d73a4a", "d73a4b", false),
+		Entry("invalid colors compared as they are", "Red", "red", true),
+	)
+})
@@ -0,0 +1,70 @@
+package labelmatch
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+var DefaultSeparators = []string{":", " - "}
+
+type labelmatch struct {
+	separators []string
+}
+
+func New(separators []string) *labelmatch {
+	labelMatch := &labelmatch{separators: separators}
+	return labelMatch
+}
+
+func fold(text string) string {
+	return strings.Join(strings.Fields(cases.Fold().String(norm.NFKC.String(text))), " ")
+}
+
+func (labelMatch labelmatch) split(labelName string) (string, string, bool) {
+	folded := fold(labelName)
+	index := -1
+	length := 0
+	for i := 0; i < len(labelMatch.separators); i++ {
+		separator := labelMatch.separators[i]
+		if strings.TrimSpace(separator) == "" {
+			continue
+		}
+		separatorIndex := strings.Index(folded, separator)
+		if separatorIndex != -1 && (index == -1 || separatorIndex < index) {
+			index = separatorIndex
+			length = len(separator)
+		}
+	}
+	if index == -1 {
+		return "", folded, false
+	}
+	prefix := strings.TrimSpace(folded[:index])
+	value := strings.TrimSpace(folded[index+length:])
+	if prefix == "" || value == "" {
+		return "", folded, false
+	}
+	return prefix, value, true
+}
+
+func (labelMatch labelmatch) Key(labelName string) string {
+	prefix, value, ok := labelMatch.split(labelName)
+	if !ok {
+		return value
+	}
+	return prefix + ": " + value
+}
+
+func (labelMatch labelmatch) Equal(labelName string, otherLabelName string) bool {
+	return labelMatch.Key(labelName) == labelMatch.Key(otherLabelName)
+}
+
+func (labelMatch labelmatch) HasPrefix(labelName string, prefix string) bool {
+	labelPrefix, _, ok := labelMatch.split(labelName)
+	return ok && prefix != "" && labelPrefix == fold(prefix)
+}
+
+func (labelMatch labelmatch) Value(labelName string) string {
+	_, value, _ := labelMatch.split(labelName)
+	return value
+}
@@ -0,0 +1,91 @@
+package labelmatch
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestLabelmatch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "labelmatch")
+}
+
+var _ = Describe("labelmatch", func() {
+	labelMatch := New(DefaultSeparators)
+
+	DescribeTable("Key",
+		func(labelName string, expectedKey string) {
+			Expect(labelMatch.Key(labelName)).To(Equal(expectedKey))
+		},
+		Entry("lowercase name", "bug", "bug"),
+		Entry("mixed case name", "Good First Issue", "good first issue"),
+		Entry("repeated and surrounding spaces", "  good   first\tissue ", "good first issue"),
+		Entry("colon with a space", "type: bug", "type: bug"),
+		Entry("colon without a space", "severity:major", "severity: major"),
+		Entry("colon with spaces around", "Type : Bug", "type: bug"),
+		Entry("dash with spaces", "Type - Bug", "type: bug"),
+		Entry("slash", "ui/ux", "ui/ux"),
+		Entry("earliest separator wins", "area - api: v2", "area: api: v2"),
+		Entry("hyphenated name", "good-first-issue", "good-first-issue"),
+		Entry("leading separator", ":bug", ":bug"),
+		Entry("trailing separator", "bug:", "bug:"),
+		Entry("fullwidth characters", "Ｔｙｐｅ：Ｂｕｇ", "type: bug"),
+		Entry("decomposed accents", "résumé", "résumé"),
+		Entry("case folding", "STRASSE", "strasse"),
+		Entry("sharp s", "Straße", "strasse"),
+		Entry("empty name", "", ""),
+	)
+
+	DescribeTable("Equal",
+		func(labelName string, otherLabelName string, expectedEqual bool) {
+			Expect(labelMatch.Equal(labelName, otherLabelName)).To(Equal(expectedEqual))
+		},
+		Entry("same name", "bug", "bug", true),
+		Entry("different case", "Type: Bug", "type: bug", true),
+		Entry("missing space", "severity:major", "severity: major", true),
+		Entry("different separators", "type - bug", "type:bug", true),
+		Entry("slash and colon", "area/api", "area: api", false),
+		Entry("different values", "type: bug", "type: feature", false),
+		Entry("different prefixes", "type: bug", "kind: bug", false),
+		Entry("prefixed and bare", "type: bug", "bug", false),
+		Entry("composed and decomposed", "café", "café", true),
+	)
+
+	DescribeTable("HasPrefix",
+		func(labelName string, prefix string, expectedHasPrefix bool) {
+			Expect(labelMatch.HasPrefix(labelName, prefix)).To(Equal(expectedHasPrefix))
+		},
+		Entry("same case", "type: bug", "type", true),
+		Entry("different case", "Type: Bug", "type", true),
+		Entry("uppercase prefix", "type: bug", "TYPE", true),
+		Entry("no space", "severity:major", "severity", true),
+		Entry("dash", "severity - major", "severity", true),
+		Entry("slash", "severity/major", "severity", false),
+		Entry("other prefix", "type: bug", "severity", false),
+		Entry("prefix of the prefix", "types: bug", "type", false),
+		Entry("no separator", "type bug", "type", false),
+		Entry("only the prefix", "type", "type", false),
+		Entry("empty value", "type:", "type", false),
+		Entry("empty prefix", "type: bug", "", false),
+	)
+
+	DescribeTable("Value",
+		func(labelName string, expectedValue string) {
+			Expect(labelMatch.Value(labelName)).To(Equal(expectedValue))
+		},
+		Entry("prefixed name", "Type: Bug", "bug"),
+		Entry("no space", "severity:Major", "major"),
+		Entry("bare name", "Bug", "bug"),
+	)
+
+	DescribeTable("custom separators",
+		func(labelName string, prefix string, expectedHasPrefix bool) {
+			Expect(New([]string{" | ", ""}).HasPrefix(labelName, prefix)).To(Equal(expectedHasPrefix))
+		},
+		Entry("custom separator", "type | bug", "type", true),
+		Entry("default separator", "type: bug", "type", false),
+		Entry("slash", "type / bug", "type", false),
+	)
+})
@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type levelEnum struct {
+	DEBUG int
+	INFO  int
+	WARN  int
+	ERROR int
+}
+
+var LevelEnum = &levelEnum{
+	DEBUG: 1,
+	INFO:  2,
+	WARN:  3,
+	ERROR: 4,
+}
+
+var levelNames = map[int]string{
+	LevelEnum.DEBUG: "debug",
+	LevelEnum.INFO:  "info",
+	LevelEnum.WARN:  "warn",
+	LevelEnum.ERROR: "error",
+}
+
+type Fields struct {
+	Repo      string
+	Issue     int
+	Rule      string
+	Operation string
+}
+
+type JsonLine struct {
+	Time      time.Time `json:"time"`
+	Level     string    `json:"level"`
+	RunId     string    `json:"runId,omitempty"`
+	Repo      string    `json:"repo,omitempty"`
+	Issue     int       `json:"issue,omitempty"`
+	Rule      string    `json:"rule,omitempty"`
+	Operation string    `json:"operation,omitempty"`
+	Message   string    `json:"msg"`
+}
+
+type systemClock struct{}
+
+func (clock systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type logger struct {
+	out    io.Writer
+	format string
+	level  int
+	clock  Clock
+	runId  string
+}
+
+var current = &logger{out: os.Stderr, format: "text", level: LevelEnum.INFO, clock: systemClock{}}
+
+const redacted = "[REDACTED]"
+
+var tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`)
+
+var secrets = []string{}
+
+var exit = os.Exit
+
+func AddSecret(secret string) {
+	if strings.TrimSpace(secret) != "" {
+		secrets = append(secrets, secret)
+	}
+}
+
+func Redact(text string) string {
+	for i := 0; i < len(secrets); i++ {
+		text = strings.ReplaceAll(text, secrets[i], redacted)
+	}
+	return tokenPattern.ReplaceAllString(text, redacted)
+}
+
+type standardLogWriter struct{}
+
+func (writer standardLogWriter) Write(data []byte) (int, error) {
+	Info(Fields{}, strings.TrimSuffix(string(data), "\n"))
+	return len(data), nil
+}
+
+func parseLevel(levelName string) (int, error) {
+	for level, name := range levelNames {
+		if name == levelName {
+			return level, nil
+		}
+	}
+	return 0, errors.New("unknown log level \"" + levelName + "\", expected debug, info, warn or error")
+}
+
+func Setup(format string, levelName string, out io.Writer, clock Clock) error {
+	if format != "text" && format != "logfmt" && format != "json" {
+		return errors.New("unknown log format \"" + format + "\", expected text, logfmt or json")
+	}
+	level, err := parseLevel(levelName)
+	if err != nil {
+		return err
+	}
+	current = &logger{out: out, format: format, level: level, clock: clock, runId: current.runId}
+	log.SetOutput(standardLogWriter{})
+	log.SetFlags(0)
+	return nil
+}
+
+func SetRunId(runId string) {
+	current.runId = runId
+}
+
+func IsDebug() bool {
+	return current.level <= LevelEnum.DEBUG
+}
+
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func formatLine(line JsonLine, format string) string {
+	if format == "json" {
+		data, _ := json.Marshal(line)
+		return string(data)
+	}
+	issue := ""
+	if line.Issue != 0 {
+		issue = strconv.Itoa(line.Issue)
+	}
+	keyValues := [][]string{
+		[]string{"runId", line.RunId},
+		[]string{"repo", line.Repo},
+		[]string{"issue", issue},
+		[]string{"rule", line.Rule},
+		[]string{"operation", line.Operation},
+	}
+	text := line.Time.Format("2006/01/02 15:04:05") + " " + strings.ToUpper(line.Level)
+	if format == "logfmt" {
+		text = "time=" + line.Time.Format(time.RFC3339) + " level=" + line.Level
+	} else {
+		keyValues = keyValues[1:]
+	}
+	for i := 0; i < len(keyValues); i++ {
+		if keyValues[i][1] != "" {
+			text += " " + keyValues[i][0] + "=" + logfmtValue(keyValues[i][1])
+		}
+	}
+	if format == "logfmt" {
+		return text + " msg=" + logfmtValue(line.Message)
+	}
+	return text + " " + line.Message
+}
+
+func write(level int, fields Fields, values []interface{}) {
+	if level < current.level {
+		return
+	}
+	line := JsonLine{
+		Time:      current.clock.Now().UTC(),
+		Level:     levelNames[level],
+		RunId:     current.runId,
+		Repo:      fields.Repo,
+		Issue:     fields.Issue,
+		Rule:      fields.Rule,
+		Operation: fields.Operation,
+		Message:   Redact(strings.TrimSuffix(fmt.Sprintln(values...), "\n")),
+	}
+	fmt.Fprintln(current.out, formatLine(line, current.format))
+}
+
+func Debug(fields Fields, values ...interface{}) {
+	write(LevelEnum.DEBUG, fields, values)
+}
+
+func Info(fields Fields, values ...interface{}) {
+	write(LevelEnum.INFO, fields, values)
+}
+
+func Warn(fields Fields, values ...interface{}) {
+	write(LevelEnum.WARN, fields, values)
+}
+
+func Error(fields Fields, values ...interface{}) {
+	write(LevelEnum.ERROR, fields, values)
+}
+
+func Fatal(fields Fields, values ...interface{}) {
+	write(LevelEnum.ERROR, fields, values)
+	exit(1)
+}
@@ -1,64 +1,1109 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/brainhubeu/issue-overseer/auditlog"
+	"github.com/brainhubeu/issue-overseer/config"
 	"github.com/brainhubeu/issue-overseer/githubclient"
 	"github.com/brainhubeu/issue-overseer/githuboperator"
 	"github.com/brainhubeu/issue-overseer/githubstructures"
 	"github.com/brainhubeu/issue-overseer/issuestriage"
+	"github.com/brainhubeu/issue-overseer/labelcolor"
+	"github.com/brainhubeu/issue-overseer/labelmatch"
+	"github.com/brainhubeu/issue-overseer/logging"
 	"github.com/brainhubeu/issue-overseer/migrations"
-	"log"
+	"github.com/brainhubeu/issue-overseer/pruneplan"
+	"github.com/brainhubeu/issue-overseer/repofilter"
+	"github.com/brainhubeu/issue-overseer/report"
+	"github.com/brainhubeu/issue-overseer/snapshot"
+	"github.com/brainhubeu/issue-overseer/taxonomy"
+	"github.com/brainhubeu/issue-overseer/tokensource"
+	"github.com/brainhubeu/issue-overseer/workcalendar"
+	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+type labelsClient interface {
+	FindRepos() []string
+	FindLabels(repoName string) []githubstructures.Label
+	FindIssuesByLabel(repoName string, labelName string) []githubstructures.Issue
+	RequestsCount() int
+}
+
+type explainOperator interface {
+	ExplainIssue(repoName string, number int) (githubstructures.IssueExplanation, bool)
+}
+
+type labelsOperator interface {
+	MergeLabelInEachRepo(repoNames []string, sourceLabelName string, targetLabelName string) []githubstructures.LabelStep
+	ApplyLabelsInEachRepo(repoNames []string, labels []githubstructures.Label, isPruning bool) []githubstructures.LabelStep
+	AuditLabels(repoNames []string) []githubstructures.LabelAudit
+	PlanLabelPrune(repoNames []string, taxonomyLabels []githubstructures.Label) []githubstructures.LabelPrunePlan
+	ApplyLabelPrune(plans []githubstructures.LabelPrunePlan) []githubstructures.LabelStep
+}
+
+type overseerOperator interface {
+	migrations.GitHubOperator
+	snapshot.GitHubOperator
+	labelsOperator
+	explainOperator
+	UpdateRepos(repoNames []string) githubstructures.RunSummary
+	ReportRepos(repoNames []string) []githubstructures.RepoReport
+}
+
+type connection struct {
+	clock          snapshot.Clock
+	githubClient   labelsClient
+	githubOperator overseerOperator
+}
+
+var commands = []command{
+	command{
+		name:              "sync",
+		arguments:         "[flags]",
+		description:       "Creates and updates the labels of the repos and labels, comments and closes their open issues.",
+		needsOrganization: true,
+		run:               runSync,
+	},
+	command{
+		name:              "daemon",
+		arguments:         "[flags]",
+		description:       "Syncs the repos again and again, pausing between the syncs.",
+		needsOrganization: true,
+		run:               runDaemon,
+	},
+	command{
+		name:        "migrate",
+		arguments:   "<command>",
+		description: "Applies, lists and rolls back the label migrations.",
+		subcommands: []command{
+			command{
+				name:        "status",
+				arguments:   "",
+				description: "Lists the migrations with the time they were applied at.",
+				run:         runMigrateStatus,
+			},
+			command{
+				name:              "up",
+				arguments:         "[flags]",
+				description:       "Applies the pending migrations.",
+				needsOrganization: true,
+				run:               runMigrateUp,
+			},
+			command{
+				name:              "down",
+				arguments:         "<migration ID>",
+				description:       "Rolls back a migration unless the labels have changed since.",
+				needsOrganization: true,
+				run:               runMigrateDown,
+			},
+		},
+	},
+	command{
+		name:        "labels",
+		arguments:   "<command>",
+		description: "Merges, exports, imports, copies, audits and prunes labels.",
+		subcommands: []command{
+			command{
+				name:              "merge",
+				arguments:         "<source label> <target label>",
+				description:       "Relabels the issues and pull requests of every selected repo from the source label to the target one and deletes the source label.",
+				needsOrganization: true,
+				run:               runLabelsMerge,
+			},
+			command{
+				name:              "export",
+				arguments:         "<repo> [flags]",
+				description:       "Exports the labels of a repo to YAML.",
+				needsOrganization: true,
+				run:               runLabelsExport,
+			},
+			command{
+				name:              "import",
+				arguments:         "<file> <repos...> [flags]",
+				description:       "Applies the labels of a YAML file to the repos.",
+				needsOrganization: true,
+				run:               runLabelsImport,
+			},
+			command{
+				name:              "copy",
+				arguments:         "<from repo> <to repos...> [flags]",
+				description:       "Applies the labels of a repo to other repos.",
+				needsOrganization: true,
+				run:               runLabelsCopy,
+			},
+			command{
+				name:              "audit",
+				arguments:         "[repos...] [flags]",
+				description:       "Reports the label drift without changing anything.",
+				needsOrganization: true,
+				run:               runLabelsAudit,
+			},
+			command{
+				name:              "prune",
+				arguments:         "[repos...] [flags]",
+				description:       "Plans, reviews and applies the deletion of unused labels and the merge of near-duplicates.",
+				needsOrganization: true,
+				run:               runLabelsPrune,
+			},
+		},
+	},
+	command{
+		name:        "explain",
+		arguments:   "<org>/<repo>#<number>",
+		description: "Explains the triage decisions and the planned changes for one issue without changing anything.",
+		run:         runExplain,
+	},
+	command{
+		name:              "report",
+		arguments:         "[flags]",
+		description:       "Reports the open issues of the repos per answering state and missing label without changing anything.",
+		needsOrganization: true,
+		run:               runReport,
+	},
+	command{
+		name:        "audit",
+		arguments:   "[flags]",
+		description: "Queries the audit log of the changes made on GitHub.",
+		run:         runAudit,
+	},
+	command{
+		name:              "snapshot",
+		arguments:         "[flags]",
+		description:       "Saves the labels of every selected repo and of its issues and pull requests to a file.",
+		needsOrganization: true,
+		run:               runSnapshot,
+	},
+	command{
+		name:              "restore",
+		arguments:         "<snapshot file> [flags]",
+		description:       "Re-creates the labels and the issue labels of a snapshot which are missing.",
+		needsOrganization: true,
+		run:               runRestore,
+	},
+	command{
+		name:        "config",
+		arguments:   "<command>",
+		description: "Checks the configuration.",
+		subcommands: []command{
+			command{
+				name:        "validate",
+				arguments:   "",
+				description: "Validates the config file and the migrations without connecting to GitHub.",
+				run:         runConfigValidate,
+			},
+		},
+	},
+}
+
 func main() {
-	organization := os.Args[1]
-	command := ""
-	if len(os.Args) > 2 {
-		command = os.Args[2]
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) (exitCode int) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error(logging.Fields{}, "failed", strings.TrimSpace(fmt.Sprint(r)))
+			exitCode = exitFailure
+		}
+	}()
+	options := globalOptions{}
+	flagSet := newGlobalFlagSet(&options, commands)
+	err := flagSet.Parse(args)
+	if err == flag.ErrHelp {
+		return exitSuccess
+	}
+	if err != nil {
+		return exitUsage
+	}
+	err = logging.Setup(options.logFormat, options.logLevel, os.Stderr, workcalendar.NewSystemClock())
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	if flagSet.NArg() == 0 {
+		flagSet.Usage()
+		return exitUsage
+	}
+	if isHelp(flagSet.Arg(0)) {
+		flagSet.Usage()
+		return exitSuccess
+	}
+	return runCommand(options, "", commands, flagSet.Args())
+}
+
+type usageFailure struct {
+	err error
+}
+
+func (failure usageFailure) Error() string {
+	return failure.err.Error()
+}
+
+func normalizeLabelColors(labels []githubstructures.Label) error {
+	for i := 0; i < len(labels); i++ {
+		color, err := labelcolor.Parse(labels[i].Color)
+		if err != nil {
+			return errors.New("label \"" + labels[i].Name + "\": " + err.Error())
+		}
+		labels[i].Color = color
+	}
+	return nil
+}
+
+func normalizeManagedLabelColors(answeringLabels []githubstructures.Label, defaultLabels []githubstructures.Label, manualLabelConfigs []githubstructures.ManualLabelConfig) error {
+	err := normalizeLabelColors(answeringLabels)
+	if err != nil {
+		return err
+	}
+	err = normalizeLabelColors(defaultLabels)
+	if err != nil {
+		return err
 	}
-	token := os.Getenv("GITHUB_TOKEN")
+	for i := 0; i < len(manualLabelConfigs); i++ {
+		color, err := labelcolor.Parse(manualLabelConfigs[i].MissingLabelColor)
+		if err != nil {
+			return errors.New("label \"missing " + manualLabelConfigs[i].Prefix + "\": " + err.Error())
+		}
+		manualLabelConfigs[i].MissingLabelColor = color
+	}
+	return nil
+}
+
+func connect(options globalOptions, issueNumbers []int) (connection, error) {
+	organization := options.organization
+	token, err := tokensource.Read(options.tokenSource)
+	if err != nil {
+		return connection{}, errors.New("no GitHub token: " + err.Error())
+	}
+	logging.AddSecret(token)
 	OUR_LABEL_TEXT := "answering: reported by " + organization
 	const ANSWERED_LABEL_TEXT = "answering: answered"
 	const NOT_ANSWERED_LABEL_TEXT = "answering: not answered"
 	answeringLabels := []githubstructures.Label{
-		githubstructures.Label{Name: OUR_LABEL_TEXT, Color: "a0a000"},
-		githubstructures.Label{Name: ANSWERED_LABEL_TEXT, Color: "00a000"},
-		githubstructures.Label{Name: NOT_ANSWERED_LABEL_TEXT, Color: "a00000"},
+		githubstructures.Label{Name: OUR_LABEL_TEXT, Color: "a0a000", Description: "Reported by " + organization + " with no external comments"},
+		githubstructures.Label{Name: ANSWERED_LABEL_TEXT, Color: "00a000", Description: "The last comment is by " + organization},
+		githubstructures.Label{Name: NOT_ANSWERED_LABEL_TEXT, Color: "a00000", Description: "Waiting for an answer from " + organization},
 	}
 
 	defaultLabels := append([]githubstructures.Label{
-		githubstructures.Label{Name: "WIP", Color: "a0a000"},
-		githubstructures.Label{Name: "blocked", Color: "000000"},
-		githubstructures.Label{Name: "hacktoberfest", Color: "202c99"},
-		githubstructures.Label{Name: "in code review", Color: "ccfeff"},
-		githubstructures.Label{Name: "needs discussion", Color: "dbf259"},
-		githubstructures.Label{Name: "needs testing", Color: "dfdf00"},
-		githubstructures.Label{Name: "no reproduction details", Color: "c91eb8"},
-		githubstructures.Label{Name: "proposed issuehunt", Color: "2803ba"},
-		githubstructures.Label{Name: "severity: blocked", Color: "000000"},
-		githubstructures.Label{Name: "severity: critical", Color: "800000"},
-		githubstructures.Label{Name: "severity: major", Color: "d00000"},
-		githubstructures.Label{Name: "severity: medium", Color: "a0a000"},
-		githubstructures.Label{Name: "severity: minor", Color: "40a000"},
-		githubstructures.Label{Name: "severity: trivial", Color: "40ff40"},
-		githubstructures.Label{Name: "tested & fails", Color: "ff4040"},
-		githubstructures.Label{Name: "tested & works", Color: "40ff40"},
+		githubstructures.Label{Name: "WIP", Color: "a0a000", Description: "Work in progress"},
+		githubstructures.Label{Name: "blocked", Color: "000000", Description: "Waiting for something else to be done first"},
+		githubstructures.Label{Name: "hacktoberfest", Color: "202c99", Description: "Good for Hacktoberfest contributors"},
+		githubstructures.Label{Name: "in code review", Color: "ccfeff", Description: "A pull request is being reviewed"},
+		githubstructures.Label{Name: "invalid label combination", Color: "e99695", Description: "A label requires a parent label which is missing"},
+		githubstructures.Label{Name: "label conflict", Color: "e99695", Description: "Mutually exclusive labels, keep only one"},
+		githubstructures.Label{Name: "needs discussion", Color: "dbf259", Description: "The solution has to be agreed on first"},
+		githubstructures.Label{Name: "needs testing", Color: "dfdf00", Description: "The fix has to be tested"},
+		githubstructures.Label{Name: "no reproduction details", Color: "c91eb8", Description: "Needs steps to reproduce the problem"},
+		githubstructures.Label{Name: "overdue", Color: "b60205", Description: "Missed its response or resolution deadline"},
+		githubstructures.Label{Name: "proposed issuehunt", Color: "2803ba", Description: "Proposed for funding on IssueHunt"},
+		githubstructures.Label{Name: "severity: blocked", Color: "000000", Description: "Blocks the work of users entirely"},
+		githubstructures.Label{Name: "severity: critical", Color: "800000", Description: "Breaks a core feature without a workaround"},
+		githubstructures.Label{Name: "severity: major", Color: "d00000", Description: "Breaks a feature, a workaround is hard"},
+		githubstructures.Label{Name: "severity: medium", Color: "a0a000", Description: "Breaks a feature, a workaround exists"},
+		githubstructures.Label{Name: "severity: minor", Color: "40a000", Description: "A small problem with an easy workaround"},
+		githubstructures.Label{Name: "severity: trivial", Color: "40ff40", Description: "A cosmetic problem"},
+		githubstructures.Label{Name: "stale", Color: "795548", Description: "No activity for a long time"},
+		githubstructures.Label{Name: "tested & fails", Color: "ff4040", Description: "Tested and the problem still occurs"},
+		githubstructures.Label{Name: "tested & works", Color: "40ff40", Description: "Tested and works as expected"},
 	}, answeringLabels...)
 	missingManualLabelPrefixes := []githubstructures.ManualLabelConfig{
-		githubstructures.ManualLabelConfig{Prefix: "type", ParentLabelName: ""},
-		githubstructures.ManualLabelConfig{Prefix: "severity", ParentLabelName: "type: bug"},
+		githubstructures.ManualLabelConfig{
+			Prefix:                  "type",
+			ParentLabelName:         "",
+			DependencyMode:          githubstructures.ManualLabelDependencyModeEnum.IGNORE,
+			MissingLabelColor:       "fbca04",
+			MissingLabelDescription: "Needs a \"type: *\" label",
+		},
+		githubstructures.ManualLabelConfig{
+			Prefix:                  "severity",
+			ParentLabelName:         "type: bug",
+			DependencyMode:          githubstructures.ManualLabelDependencyModeEnum.FLAG,
+			MissingLabelColor:       "f9d0c4",
+			MissingLabelDescription: "Needs a \"severity: *\" label",
+		},
+	}
+
+	err = normalizeManagedLabelColors(answeringLabels, defaultLabels, missingManualLabelPrefixes)
+	if err != nil {
+		return connection{}, usageFailure{err}
 	}
 
-	log.Println(token, OUR_LABEL_TEXT, ANSWERED_LABEL_TEXT, NOT_ANSWERED_LABEL_TEXT)
+	repoConfigs := config.New()
+	if options.configPath != "" {
+		repoConfigs, err = config.Load(options.configPath)
+		if err != nil {
+			return connection{}, usageFailure{errors.New("invalid config " + options.configPath + ": " + err.Error())}
+		}
+	}
+	missingManualLabelPrefixes, err = repoConfigs.ManualLabelConfigs(missingManualLabelPrefixes)
+	if err != nil {
+		return connection{}, usageFailure{errors.New("invalid config " + options.configPath + ": " + err.Error())}
+	}
 
+	clock := workcalendar.NewSystemClock()
+	runId := auditlog.NewRunId(clock)
+	logging.SetRunId(runId)
 	githubClient := githubclient.New(organization, token)
-	issuesTriage := issuestriage.New()
-	githubOperator := githuboperator.New(githubClient, issuesTriage, answeringLabels, OUR_LABEL_TEXT, ANSWERED_LABEL_TEXT, NOT_ANSWERED_LABEL_TEXT, defaultLabels, missingManualLabelPrefixes)
-	repoNames := githubClient.FindRepos()
-	log.Println("repoNames", repoNames)
-	if command == "migrations" {
-		migrations.Up(githubOperator, repoNames)
+	tokenInfo := githubClient.FindTokenInfo()
+	if tokenInfo.IsApp {
+		logging.Info(logging.Fields{}, "GitHub token of an app installation with access to", tokenInfo.RepositoriesCount, "repos")
 	} else {
-		githubOperator.UpdateRepos(repoNames)
+		logging.Info(logging.Fields{}, "GitHub token of", tokenInfo.Login, "with the scopes", tokenInfo.Scopes)
+	}
+	labelMatcher := labelmatch.New(repoConfigs.LabelSeparators())
+	issuesTriage := issuestriage.New(labelMatcher)
+	githubOperator := githuboperator.New(githubClient, issuesTriage, answeringLabels, OUR_LABEL_TEXT, ANSWERED_LABEL_TEXT, NOT_ANSWERED_LABEL_TEXT, defaultLabels, missingManualLabelPrefixes, repoConfigs, clock, auditlog.New(envOr("AUDIT_LOG_PATH", "audit.jsonl"), runId, clock), labelMatcher)
+	if options.isDryRun {
+		logging.Info(logging.Fields{}, "dry run, nothing is changed on GitHub")
+		githubOperator = githubOperator.DryRun()
+	}
+	if len(issueNumbers) > 0 {
+		githubOperator = githubOperator.ForIssues(issueNumbers)
+	}
+	return connection{clock: clock, githubClient: githubClient, githubOperator: githubOperator}, nil
+}
+
+func connectionFailure(err error) int {
+	logging.Error(logging.Fields{}, err)
+	if _, ok := err.(usageFailure); ok {
+		return exitUsage
+	}
+	return exitFailure
+}
+
+type repoFilterFlags struct {
+	repoNames stringsFlag
+	includes  stringsFlag
+	excludes  stringsFlag
+}
+
+func addRepoFilterFlags(flagSet *flag.FlagSet) *repoFilterFlags {
+	filterFlags := &repoFilterFlags{}
+	flagSet.Var(&filterFlags.repoNames, "repo", "only this repo (repeatable)")
+	flagSet.Var(&filterFlags.includes, "include", "only the repos matching this glob, e.g. \"web-*\" (repeatable)")
+	flagSet.Var(&filterFlags.excludes, "exclude", "skip the repos matching this glob (repeatable)")
+	return filterFlags
+}
+
+func addIssueFlag(flagSet *flag.FlagSet) *stringsFlag {
+	issueNumbers := &stringsFlag{}
+	flagSet.Var(issueNumbers, "issue", "only this issue or pull request number of the selected repo, repo labels are left untouched (repeatable)")
+	return issueNumbers
+}
+
+func (filterFlags repoFilterFlags) selectRepos(githubClient labelsClient, issueNumbers []int) ([]string, bool, error) {
+	repoFilter, err := repofilter.New(filterFlags.repoNames, filterFlags.includes, filterFlags.excludes)
+	if err != nil {
+		return nil, false, err
+	}
+	repoNames, err := repoFilter.Select(githubClient.FindRepos())
+	if err != nil {
+		return nil, false, err
+	}
+	if len(issueNumbers) > 0 && len(repoNames) != 1 {
+		return nil, false, errors.New("--issue needs exactly one selected repo, got " + strconv.Itoa(len(repoNames)))
+	}
+	logging.Info(logging.Fields{}, "selected", len(repoNames), "repos:", strings.Join(repoNames, ", "))
+	return repoNames, repoFilter.IsPartial() || len(issueNumbers) > 0, nil
+}
+
+type summaryFlags struct {
+	format *string
+	output *string
+}
+
+func addSummaryFlags(flagSet *flag.FlagSet) *summaryFlags {
+	return &summaryFlags{
+		format: flagSet.String("summary-format", "markdown", "the format of the --summary-output file: markdown or json"),
+		output: flagSet.String("summary-output", "", "also write the run summary to this file"),
+	}
+}
+
+func (flags summaryFlags) validate() error {
+	if *flags.format != "markdown" && *flags.format != "json" {
+		return errors.New("unknown summary format \"" + *flags.format + "\", expected markdown or json")
+	}
+	return nil
+}
+
+func (flags summaryFlags) write(summary githubstructures.RunSummary) int {
+	table := report.RunSummaryTable(summary)
+	fmt.Print(table.Markdown())
+	if *flags.output == "" {
+		return exitSuccess
+	}
+	data, err := report.Format(*flags.format, table, summary)
+	if err != nil {
+		logging.Error(logging.Fields{}, "cannot format the run summary", err)
+		return exitFailure
+	}
+	return writeOutput(*flags.output, []byte(data))
+}
+
+func syncRepo(connection connection, repoName string) (repoSummary githubstructures.RepoSummary) {
+	startedAt := connection.clock.Now()
+	requestsCount := connection.githubClient.RequestsCount()
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error(logging.Fields{Repo: repoName}, "sync failed", strings.TrimSpace(fmt.Sprint(r)))
+			repoSummary = githubstructures.RepoSummary{RepoName: repoName, ErrorsCount: 1}
+		}
+		repoSummary.RequestsCount = connection.githubClient.RequestsCount() - requestsCount
+		repoSummary.Duration = connection.clock.Now().Sub(startedAt)
+	}()
+	summary := connection.githubOperator.UpdateRepos([]string{repoName})
+	for i := 0; i < len(summary.Repos); i++ {
+		repoSummary = summary.Repos[i]
+		logging.Info(logging.Fields{Repo: repoSummary.RepoName}, "orphaned labels removed", len(repoSummary.OrphanedLabelRemovals), repoSummary.OrphanedLabelRemovals)
+	}
+	return repoSummary
+}
+
+func syncOnce(options globalOptions, flagSet *flag.FlagSet, filterFlags *repoFilterFlags, issueValues stringsFlag, summaryFlags *summaryFlags) int {
+	issueNumbers, err := parseIssueNumbers(issueValues)
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	err = summaryFlags.validate()
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	connection, err := connect(options, issueNumbers)
+	if err != nil {
+		return connectionFailure(err)
+	}
+	repoNames, _, err := filterFlags.selectRepos(connection.githubClient, issueNumbers)
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	summary := githubstructures.RunSummary{Repos: []githubstructures.RepoSummary{}}
+	failedCount := 0
+	for i := 0; i < len(repoNames); i++ {
+		repoSummary := syncRepo(connection, repoNames[i])
+		if repoSummary.ErrorsCount > 0 {
+			failedCount++
+		}
+		summary.Repos = append(summary.Repos, repoSummary)
+	}
+	logging.Info(logging.Fields{}, "synced", len(repoNames)-failedCount, "of", len(repoNames), "repos")
+	if summaryFlags.write(summary) != exitSuccess {
+		return exitFailure
+	}
+	return exitCodeOf(len(repoNames), failedCount)
+}
+
+func runSync(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	filterFlags := addRepoFilterFlags(flagSet)
+	issueValues := addIssueFlag(flagSet)
+	summaryFlags := addSummaryFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	return syncOnce(options, flagSet, filterFlags, *issueValues, summaryFlags)
+}
+
+func daemonSync(options globalOptions, flagSet *flag.FlagSet, filterFlags *repoFilterFlags, summaryFlags *summaryFlags) (exitCode int) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error(logging.Fields{}, "sync failed", strings.TrimSpace(fmt.Sprint(r)))
+			exitCode = exitFailure
+		}
+	}()
+	return syncOnce(options, flagSet, filterFlags, stringsFlag{}, summaryFlags)
+}
+
+func runDaemon(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	interval := flagSet.Duration("interval", 5*time.Minute, "the pause between two syncs")
+	filterFlags := addRepoFilterFlags(flagSet)
+	summaryFlags := addSummaryFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	for {
+		exitCode := daemonSync(options, flagSet, filterFlags, summaryFlags)
+		if exitCode == exitUsage {
+			return exitCode
+		}
+		logging.Info(logging.Fields{}, "sync finished with exit code", exitCode, "next sync in", *interval)
+		time.Sleep(*interval)
+	}
+}
+
+func loadMigrations() ([]migrations.Migration, migrations.Ledger) {
+	migrationsDir := envOr("MIGRATIONS_DIR", "migrations")
+	ledgerPath := envOr("MIGRATIONS_LEDGER_PATH", "migrations-ledger.json")
+	allMigrations, err := migrations.All(migrationsDir)
+	if err != nil {
+		logging.Fatal(logging.Fields{}, "invalid migrations", migrationsDir, err)
+	}
+	ledger, err := migrations.LoadLedger(ledgerPath)
+	if err != nil {
+		logging.Fatal(logging.Fields{}, "invalid migrations ledger", ledgerPath, err)
+	}
+	return allMigrations, ledger
+}
+
+func runMigrateStatus(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	allMigrations, ledger := loadMigrations()
+	records := migrations.Status(ledger, allMigrations)
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record.AppliedAt != nil {
+			fmt.Println(record.Id, "applied at", record.AppliedAt.Format(time.RFC3339))
+		} else {
+			fmt.Println(record.Id, "pending, applied to", len(record.Repos), "repos")
+		}
+	}
+	return exitSuccess
+}
+
+func runMigrateUp(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	toId := flagSet.String("to", "", "apply the pending migrations up to and including this ID")
+	filterFlags := addRepoFilterFlags(flagSet)
+	issueValues := addIssueFlag(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	issueNumbers, err := parseIssueNumbers(*issueValues)
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	allMigrations, ledger := loadMigrations()
+	connection, err := connect(options, issueNumbers)
+	if err != nil {
+		return connectionFailure(err)
+	}
+	repoNames, isPartial, err := filterFlags.selectRepos(connection.githubClient, issueNumbers)
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	if len(issueNumbers) > 0 || options.isDryRun {
+		ledger = migrations.ReadOnly(ledger)
+	}
+	err = migrations.Up(connection.githubOperator, ledger, connection.clock, allMigrations, repoNames, *toId, isPartial)
+	if err != nil {
+		logging.Error(logging.Fields{}, "migrations failed", err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func runMigrateDown(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) != 1 {
+		return usageError(flagSet, "expected a migration ID")
+	}
+	_, ledger := loadMigrations()
+	if options.isDryRun {
+		ledger = migrations.ReadOnly(ledger)
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	err = migrations.Down(connection.githubOperator, ledger, positionalArgs[0])
+	if err != nil {
+		logging.Error(logging.Fields{}, "rollback failed", err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func printLabelSteps(steps []githubstructures.LabelStep) {
+	for i := 0; i < len(steps); i++ {
+		step := steps[i]
+		switch step.Kind {
+		case githubstructures.LabelStepKindEnum.CREATE_LABEL:
+			fmt.Println(step.RepoName+":", "created", strconv.Quote(step.LabelName))
+		case githubstructures.LabelStepKindEnum.UPDATE_LABEL:
+			fmt.Println(step.RepoName+":", "updated", strconv.Quote(step.LabelName))
+		case githubstructures.LabelStepKindEnum.DELETE_LABEL:
+			fmt.Println(step.RepoName+":", "deleted", strconv.Quote(step.LabelName))
+		}
+	}
+}
+
+func countRelabelled(githubClient labelsClient, steps []githubstructures.LabelStep, isDryRun bool) int {
+	relabelledCount := 0
+	for i := 0; i < len(steps); i++ {
+		if steps[i].Kind == githubstructures.LabelStepKindEnum.REMOVE_ISSUE_LABEL {
+			relabelledCount++
+		}
+		if steps[i].Kind == githubstructures.LabelStepKindEnum.RENAME_LABEL {
+			labelName := steps[i].NewLabelName
+			if isDryRun {
+				labelName = steps[i].LabelName
+			}
+			relabelledCount += len(githubClient.FindIssuesByLabel(steps[i].RepoName, labelName))
+		}
+	}
+	return relabelledCount
+}
+
+func runLabelsMerge(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	filterFlags := addRepoFilterFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) != 2 {
+		return usageError(flagSet, "expected a source and a target label")
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	repoNames, _, err := filterFlags.selectRepos(connection.githubClient, []int{})
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	steps := connection.githubOperator.MergeLabelInEachRepo(repoNames, positionalArgs[0], positionalArgs[1])
+	fmt.Println("merged", positionalArgs[0], "into", positionalArgs[1], "relabelling", countRelabelled(connection.githubClient, steps, options.isDryRun), "issues and pull requests")
+	return exitSuccess
+}
+
+func runLabelsExport(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	output := flagSet.String("output", "", "the file to write to, the standard output by default")
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) != 1 {
+		return usageError(flagSet, "expected a repo")
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	data, err := taxonomy.Export(connection.githubClient.FindLabels(positionalArgs[0]))
+	if err != nil {
+		logging.Error(logging.Fields{}, "cannot export the labels", err)
+		return exitFailure
+	}
+	return writeOutput(*output, data)
+}
+
+func runLabelsImport(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	isPruning := flagSet.Bool("prune", false, "delete the labels which are not in the imported set")
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) < 2 {
+		return usageError(flagSet, "expected a labels file and repos")
+	}
+	labels, err := taxonomy.Load(positionalArgs[0])
+	if err != nil {
+		logging.Error(logging.Fields{}, "invalid labels file", positionalArgs[0], err)
+		return exitFailure
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	printLabelSteps(connection.githubOperator.ApplyLabelsInEachRepo(positionalArgs[1:], labels, *isPruning))
+	return exitSuccess
+}
+
+func runLabelsCopy(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	isPruning := flagSet.Bool("prune", false, "delete the labels which are not in the copied set")
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) < 2 {
+		return usageError(flagSet, "expected a source repo and target repos")
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	labels := connection.githubClient.FindLabels(positionalArgs[0])
+	printLabelSteps(connection.githubOperator.ApplyLabelsInEachRepo(positionalArgs[1:], labels, *isPruning))
+	return exitSuccess
+}
+
+func runLabelsAudit(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	format := flagSet.String("format", "markdown", "the audit format: markdown, csv or json")
+	output := flagSet.String("output", "", "the file to write to, the standard output by default")
+	filterFlags := addRepoFilterFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	filterFlags.repoNames = append(filterFlags.repoNames, positionalArgs...)
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	repoNames, _, err := filterFlags.selectRepos(connection.githubClient, []int{})
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	audits := connection.githubOperator.AuditLabels(repoNames)
+	data, err := report.Format(*format, report.LabelAuditTable(audits), audits)
+	if err != nil {
+		return usageError(flagSet, "cannot format the audit", err)
+	}
+	return writeOutput(*output, []byte(data))
+}
+
+func runLabelsPrune(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	output := flagSet.String("output", "", "the file to write the plan to, the standard output by default")
+	taxonomyPath := flagSet.String("taxonomy", "", "a labels file whose labels are never pruned, in addition to the default labels")
+	planPath := flagSet.String("plan", "", "apply this reviewed prune plan")
+	isInteractive := flagSet.Bool("interactive", false, "confirm every pruning and apply the confirmed ones")
+	filterFlags := addRepoFilterFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	filterFlags.repoNames = append(filterFlags.repoNames, positionalArgs...)
+	if *planPath != "" {
+		plans, err := pruneplan.Load(*planPath)
+		if err != nil {
+			logging.Error(logging.Fields{}, "invalid prune plan", *planPath, err)
+			return exitFailure
+		}
+		connection, err := connect(options, []int{})
+		if err != nil {
+			return connectionFailure(err)
+		}
+		printLabelSteps(connection.githubOperator.ApplyLabelPrune(plans))
+		return exitSuccess
+	}
+	taxonomyLabels := []githubstructures.Label{}
+	if *taxonomyPath != "" {
+		taxonomyLabels, err = taxonomy.Load(*taxonomyPath)
+		if err != nil {
+			logging.Error(logging.Fields{}, "invalid labels file", *taxonomyPath, err)
+			return exitFailure
+		}
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	repoNames, _, err := filterFlags.selectRepos(connection.githubClient, []int{})
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	plans := connection.githubOperator.PlanLabelPrune(repoNames, taxonomyLabels)
+	if *isInteractive {
+		printLabelSteps(connection.githubOperator.ApplyLabelPrune(pruneplan.Review(plans, os.Stdin, os.Stdout)))
+		return exitSuccess
+	}
+	data, err := pruneplan.Export(plans)
+	if err != nil {
+		logging.Error(logging.Fields{}, "cannot export the prune plan", err)
+		return exitFailure
+	}
+	return writeOutput(*output, data)
+}
+
+func writeOutput(output string, data []byte) int {
+	if output == "" {
+		os.Stdout.Write(data)
+		return exitSuccess
+	}
+	err := ioutil.WriteFile(output, data, 0644)
+	if err != nil {
+		logging.Error(logging.Fields{}, "cannot write", output, err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func runReport(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	format := flagSet.String("format", "markdown", "the report format: markdown, csv or json")
+	output := flagSet.String("output", "", "the file to write to, the standard output by default")
+	filterFlags := addRepoFilterFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	repoNames, _, err := filterFlags.selectRepos(connection.githubClient, []int{})
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	reports := connection.githubOperator.ReportRepos(repoNames)
+	data, err := report.Format(*format, report.RepoReportTable(reports), reports)
+	if err != nil {
+		return usageError(flagSet, "cannot format the report", err)
+	}
+	return writeOutput(*output, []byte(data))
+}
+
+func runAudit(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	repoName := flagSet.String("repo", "", "only show the entries of this repo")
+	issueNumber := flagSet.Int("issue", 0, "only show the entries of this issue or pull request number")
+	rule := flagSet.String("rule", "", "only show the entries caused by this rule, e.g. stale or migration")
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	auditLogPath := envOr("AUDIT_LOG_PATH", "audit.jsonl")
+	entries, err := auditlog.Query(auditLogPath, auditlog.Filter{Repo: *repoName, Issue: *issueNumber, Rule: *rule})
+	if err != nil {
+		logging.Error(logging.Fields{}, "invalid audit log", auditLogPath, err)
+		return exitFailure
+	}
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		target := entry.Repo
+		if entry.Issue != 0 {
+			target += "#" + strconv.Itoa(entry.Issue)
+		}
+		fmt.Println(entry.Time.Format(time.RFC3339), entry.RunId, target, entry.Rule, entry.Operation, strconv.Quote(entry.Before), "->", strconv.Quote(entry.After))
+	}
+	return exitSuccess
+}
+
+func runSnapshot(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	output := flagSet.String("output", "", "the file to write the snapshot to, snapshot-<time>.json by default")
+	filterFlags := addRepoFilterFlags(flagSet)
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	if *output == "" {
+		*output = "snapshot-" + connection.clock.Now().UTC().Format("20060102T150405Z") + ".json"
+	}
+	repoNames, _, err := filterFlags.selectRepos(connection.githubClient, []int{})
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	orgSnapshot := snapshot.Take(connection.githubOperator, connection.clock, options.organization, repoNames)
+	err = snapshot.Write(*output, orgSnapshot)
+	if err != nil {
+		logging.Error(logging.Fields{}, "cannot write the snapshot", *output, err)
+		return exitFailure
+	}
+	fmt.Println("snapshot of", len(orgSnapshot.Repos), "repos written to", *output)
+	return exitSuccess
+}
+
+func runRestore(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	repoNames := stringsFlag{}
+	flagSet.Var(&repoNames, "repo", "only restore this repo (repeatable)")
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) != 1 {
+		return usageError(flagSet, "expected a snapshot file")
+	}
+	path := positionalArgs[0]
+	orgSnapshot, err := snapshot.Read(path)
+	if err != nil {
+		logging.Error(logging.Fields{}, "invalid snapshot", path, err)
+		return exitFailure
+	}
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	steps, err := snapshot.Restore(connection.githubOperator, orgSnapshot, repoNames, options.isDryRun)
+	if err != nil {
+		logging.Error(logging.Fields{}, "restore failed", err)
+		return exitFailure
+	}
+	prefix := ""
+	if options.isDryRun {
+		prefix = "would "
+	}
+	for i := 0; i < len(steps); i++ {
+		step := steps[i]
+		if step.Kind == githubstructures.LabelStepKindEnum.CREATE_LABEL {
+			fmt.Println(step.RepoName+":", prefix+"create label", strconv.Quote(step.LabelName))
+		} else {
+			fmt.Println(step.IssueUrl+":", prefix+"add label", strconv.Quote(step.LabelName))
+		}
+	}
+	fmt.Println(len(steps), "changes")
+	return exitSuccess
+}
+
+func runConfigValidate(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) > 0 {
+		return usageError(flagSet, "unexpected arguments")
+	}
+	exitCode := exitSuccess
+	if options.configPath == "" {
+		fmt.Println("no config file, the defaults are used")
+	} else if _, err := config.Load(options.configPath); err != nil {
+		fmt.Println("invalid config", options.configPath+":", err)
+		exitCode = exitFailure
+	} else {
+		fmt.Println("valid config", options.configPath)
+	}
+	migrationsDir := envOr("MIGRATIONS_DIR", "migrations")
+	allMigrations, err := migrations.All(migrationsDir)
+	if err != nil {
+		fmt.Println("invalid migrations", migrationsDir+":", err)
+		return exitFailure
+	}
+	fmt.Println("valid migrations in", migrationsDir+",", len(allMigrations), "including the built-in ones")
+	return exitCode
+}
+
+func parseIssueReference(reference string) (string, string, int, error) {
+	invalidReferenceError := errors.New("invalid issue reference \"" + reference + "\", expected <org>/<repo>#<number>")
+	i := strings.Index(reference, "/")
+	j := strings.LastIndex(reference, "#")
+	if i <= 0 || j <= i+1 {
+		return "", "", 0, invalidReferenceError
+	}
+	number, err := strconv.Atoi(reference[j+1:])
+	if err != nil || number <= 0 {
+		return "", "", 0, invalidReferenceError
+	}
+	return reference[:i], reference[i+1 : j], number, nil
+}
+
+var decisionTypeNames = map[string]map[int]string{
+	"answering": map[int]string{
+		githubstructures.IssueAnsweringTypeEnum.OURS:         "ours",
+		githubstructures.IssueAnsweringTypeEnum.ANSWERED:     "answered",
+		githubstructures.IssueAnsweringTypeEnum.NOT_ANSWERED: "not answered",
+	},
+	"missing labels": map[int]string{
+		githubstructures.IssueManualLabelTypeEnum.EXISTENT:     "labelled",
+		githubstructures.IssueManualLabelTypeEnum.NON_EXISTENT: "missing",
+	},
+	"exclusive groups": map[int]string{
+		githubstructures.IssueExclusiveGroupTypeEnum.CONSISTENT: "consistent",
+		githubstructures.IssueExclusiveGroupTypeEnum.RESOLVED:   "resolved",
+		githubstructures.IssueExclusiveGroupTypeEnum.CONFLICT:   "conflict",
+	},
+	"orphaned labels": map[int]string{
+		githubstructures.IssueOrphanedLabelsTypeEnum.VALID:   "valid",
+		githubstructures.IssueOrphanedLabelsTypeEnum.REMOVED: "removed",
+		githubstructures.IssueOrphanedLabelsTypeEnum.FLAGGED: "flagged",
+	},
+	"overdue": map[int]string{
+		githubstructures.IssueDeadlineTypeEnum.ON_TIME: "on time",
+		githubstructures.IssueDeadlineTypeEnum.OVERDUE: "overdue",
+	},
+	"escalation": map[int]string{
+		githubstructures.IssueEscalationTypeEnum.NOT_ESCALATED: "not escalated",
+		githubstructures.IssueEscalationTypeEnum.ESCALATED:     "escalated",
+	},
+	"stale": map[int]string{
+		githubstructures.IssueStaleTypeEnum.ACTIVE:         "active",
+		githubstructures.IssueStaleTypeEnum.BECOMING_STALE: "becoming stale",
+		githubstructures.IssueStaleTypeEnum.STALE:          "stale",
+		githubstructures.IssueStaleTypeEnum.REVIVED:        "revived",
+		githubstructures.IssueStaleTypeEnum.TO_CLOSE:       "to close",
+	},
+}
+
+func formatComment(comment githubstructures.Comment) string {
+	return "@" + comment.AuthorLogin + " (" + comment.AuthorAssociation + ") at " + comment.CreatedAt.Format(time.RFC3339)
+}
+
+func runExplain(options globalOptions, flagSet *flag.FlagSet, args []string) int {
+	positionalArgs, err := parseFlags(flagSet, args)
+	if err != nil {
+		return flagErrorExitCode(err)
+	}
+	if len(positionalArgs) != 1 {
+		return usageError(flagSet, "expected an issue reference")
+	}
+	organization, repoName, number, err := parseIssueReference(positionalArgs[0])
+	if err != nil {
+		return usageError(flagSet, err)
+	}
+	options.organization = organization
+	connection, err := connect(options, []int{})
+	if err != nil {
+		return connectionFailure(err)
+	}
+	explanation, ok := connection.githubOperator.ExplainIssue(repoName, number)
+	if !ok {
+		logging.Error(logging.Fields{Repo: repoName, Issue: number}, "issue not found")
+		return exitFailure
+	}
+	fmt.Println(explanation.Issue.Url, explanation.Issue.Title)
+	for i := 0; i < len(explanation.Decisions); i++ {
+		ruleDecision := explanation.Decisions[i]
+		decision := ruleDecision.Decision
+		typeNames := decisionTypeNames[strings.SplitN(ruleDecision.Rule, ":", 2)[0]]
+		fmt.Println(ruleDecision.Rule+":", typeNames[decision.Type], "-", decision.Reason)
+		if decision.DecidingComment != nil {
+			fmt.Println("  deciding comment:", formatComment(*decision.DecidingComment))
+		}
+		for j := 0; j < len(decision.SkippedComments); j++ {
+			skippedComment := decision.SkippedComments[j]
+			fmt.Println("  skipped comment:", formatComment(skippedComment.Comment), "-", skippedComment.Reason)
+		}
+	}
+	if len(explanation.PlannedChanges) == 0 {
+		fmt.Println("no planned changes")
+		return exitSuccess
+	}
+	fmt.Println("planned changes:")
+	for i := 0; i < len(explanation.PlannedChanges); i++ {
+		change := explanation.PlannedChanges[i]
+		switch change.Operation {
+		case "AddLabel":
+			fmt.Println("  "+change.Rule+":", "add label", strconv.Quote(change.After))
+		case "RemoveLabel":
+			fmt.Println("  "+change.Rule+":", "remove label", strconv.Quote(change.Before))
+		case "CreateComment":
+			fmt.Println("  "+change.Rule+":", "comment", strconv.Quote(change.After))
+		default:
+			fmt.Println("  "+change.Rule+":", change.Operation)
+		}
 	}
+	return exitSuccess
 }
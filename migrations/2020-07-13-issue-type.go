@@ -1,12 +1,11 @@
 package migrations
 
 import (
-	"log"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
 )
 
-func up_2020_07_13_issue_type(githubOperator GitHubOperator, repoNames []string) {
-	githubOperator.RenameLabelInEachRepo(repoNames, "bug", "type: bug")
-	githubOperator.RenameLabelInEachRepo(repoNames, "enhancement", "type: enhancement")
-	githubOperator.RenameLabelInEachRepo(repoNames, "question", "type: question")
-	log.Println("2020-07-13-issue-type migration finished")
+func up_2020_07_13_issue_type(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+	steps := githubOperator.RenameLabelInEachRepo(repoNames, "bug", "type: bug")
+	steps = append(steps, githubOperator.RenameLabelInEachRepo(repoNames, "enhancement", "type: enhancement")...)
+	return append(steps, githubOperator.RenameLabelInEachRepo(repoNames, "question", "type: question")...)
 }
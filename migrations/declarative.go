@@ -0,0 +1,215 @@
+package migrations
+
+import (
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/labelcolor"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type RenameYaml struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+type RecolorYaml struct {
+	Label string `yaml:"label"`
+	Color string `yaml:"color"`
+}
+
+type DeleteIfUnusedYaml struct {
+	Label string `yaml:"label"`
+}
+
+type SplitRuleYaml struct {
+	Label        string `yaml:"label"`
+	TitleMatches string `yaml:"titleMatches"`
+	HasLabel     string `yaml:"hasLabel"`
+	PullRequest  *bool  `yaml:"pullRequest"`
+}
+
+type SplitYaml struct {
+	Label     string          `yaml:"label"`
+	Rules     []SplitRuleYaml `yaml:"rules"`
+	Otherwise string          `yaml:"otherwise"`
+}
+
+type OperationYaml struct {
+	Rename         *RenameYaml         `yaml:"rename"`
+	Merge          *RenameYaml         `yaml:"merge"`
+	Recolor        *RecolorYaml        `yaml:"recolor"`
+	DeleteIfUnused *DeleteIfUnusedYaml `yaml:"deleteIfUnused"`
+	Split          *SplitYaml          `yaml:"split"`
+}
+
+type MigrationYaml struct {
+	Operations []OperationYaml `yaml:"operations"`
+}
+
+type operation func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep
+
+func parseRename(renameYaml RenameYaml) (string, string, error) {
+	if renameYaml.From == "" || renameYaml.To == "" {
+		return "", "", errors.New("both from and to are required")
+	}
+	return renameYaml.From, renameYaml.To, nil
+}
+
+func parseSplit(splitYaml SplitYaml) (operation, error) {
+	if splitYaml.Label == "" {
+		return nil, errors.New("split requires a label")
+	}
+	if len(splitYaml.Rules) == 0 && splitYaml.Otherwise == "" {
+		return nil, errors.New("split requires rules or an otherwise label")
+	}
+	rules := []githubstructures.LabelSplitRule{}
+	for i := 0; i < len(splitYaml.Rules); i++ {
+		ruleYaml := splitYaml.Rules[i]
+		if ruleYaml.Label == "" {
+			return nil, errors.New("split rule #" + strconv.Itoa(i+1) + " requires a label")
+		}
+		rule := githubstructures.LabelSplitRule{LabelName: ruleYaml.Label, HasLabelName: ruleYaml.HasLabel, IsPullRequest: ruleYaml.PullRequest}
+		if ruleYaml.TitleMatches != "" {
+			titlePattern, err := regexp.Compile(ruleYaml.TitleMatches)
+			if err != nil {
+				return nil, errors.New("split rule #" + strconv.Itoa(i+1) + ": invalid titleMatches: " + err.Error())
+			}
+			rule.TitlePattern = titlePattern
+		}
+		rules = append(rules, rule)
+	}
+	return func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+		return githubOperator.SplitLabelInEachRepo(repoNames, splitYaml.Label, rules, splitYaml.Otherwise)
+	}, nil
+}
+
+func parseOperation(operationYaml OperationYaml) (operation, error) {
+	operations := []operation{}
+	if operationYaml.Rename != nil {
+		from, to, err := parseRename(*operationYaml.Rename)
+		if err != nil {
+			return nil, errors.New("rename: " + err.Error())
+		}
+		operations = append(operations, func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+			return githubOperator.RenameLabelInEachRepo(repoNames, from, to)
+		})
+	}
+	if operationYaml.Merge != nil {
+		from, to, err := parseRename(*operationYaml.Merge)
+		if err != nil {
+			return nil, errors.New("merge: " + err.Error())
+		}
+		operations = append(operations, func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+			return githubOperator.MergeLabelInEachRepo(repoNames, from, to)
+		})
+	}
+	if operationYaml.Recolor != nil {
+		recolorYaml := *operationYaml.Recolor
+		if recolorYaml.Label == "" || recolorYaml.Color == "" {
+			return nil, errors.New("recolor: both label and color are required")
+		}
+		color, err := labelcolor.Parse(recolorYaml.Color)
+		if err != nil {
+			return nil, errors.New("recolor: " + err.Error())
+		}
+		operations = append(operations, func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+			return githubOperator.RecolorLabelInEachRepo(repoNames, recolorYaml.Label, color)
+		})
+	}
+	if operationYaml.DeleteIfUnused != nil {
+		labelName := operationYaml.DeleteIfUnused.Label
+		if labelName == "" {
+			return nil, errors.New("deleteIfUnused: label is required")
+		}
+		operations = append(operations, func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+			return githubOperator.DeleteUnusedLabelInEachRepo(repoNames, labelName)
+		})
+	}
+	if operationYaml.Split != nil {
+		split, err := parseSplit(*operationYaml.Split)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, split)
+	}
+	if len(operations) != 1 {
+		return nil, errors.New("expected exactly one of rename, merge, recolor, deleteIfUnused or split")
+	}
+	return operations[0], nil
+}
+
+func ParseMigration(id string, data []byte) (Migration, error) {
+	migrationYaml := MigrationYaml{}
+	err := yaml.UnmarshalStrict(data, &migrationYaml)
+	if err != nil {
+		return Migration{}, errors.New("migration \"" + id + "\": " + err.Error())
+	}
+	if len(migrationYaml.Operations) == 0 {
+		return Migration{}, errors.New("migration \"" + id + "\": no operations")
+	}
+	operations := []operation{}
+	for i := 0; i < len(migrationYaml.Operations); i++ {
+		parsedOperation, err := parseOperation(migrationYaml.Operations[i])
+		if err != nil {
+			return Migration{}, errors.New("migration \"" + id + "\": operation #" + strconv.Itoa(i+1) + ": " + err.Error())
+		}
+		operations = append(operations, parsedOperation)
+	}
+	return Migration{Id: id, Up: func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+		steps := []githubstructures.LabelStep{}
+		for i := 0; i < len(operations); i++ {
+			steps = append(steps, operations[i](githubOperator, repoNames)...)
+		}
+		return steps
+	}}, nil
+}
+
+func LoadDir(dir string) ([]Migration, error) {
+	fileInfos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Migration{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	migrations := []Migration{}
+	for i := 0; i < len(fileInfos); i++ {
+		fileName := fileInfos[i].Name()
+		extension := filepath.Ext(fileName)
+		if fileInfos[i].IsDir() || extension != ".yml" && extension != ".yaml" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+		migration, err := ParseMigration(strings.TrimSuffix(fileName, extension), data)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+func All(dir string) ([]Migration, error) {
+	declarativeMigrations, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	migrations := append(append([]Migration{}, builtinMigrations...), declarativeMigrations...)
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].Id < migrations[j].Id })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Id == migrations[i-1].Id {
+			return nil, errors.New("duplicated migration \"" + migrations[i].Id + "\"")
+		}
+	}
+	return migrations, nil
+}
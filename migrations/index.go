@@ -1,14 +1,145 @@
 package migrations
 
 import (
-	"log"
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/logging"
+	"sort"
+	"strings"
+	"time"
 )
 
 type GitHubOperator interface {
-	RenameLabelInEachRepo(repoNames []string, oldLabelName string, newLabelName string)
+	RenameLabelInEachRepo(repoNames []string, oldLabelName string, newLabelName string) []githubstructures.LabelStep
+	MergeLabelInEachRepo(repoNames []string, sourceLabelName string, targetLabelName string) []githubstructures.LabelStep
+	RecolorLabelInEachRepo(repoNames []string, labelName string, color string) []githubstructures.LabelStep
+	DeleteUnusedLabelInEachRepo(repoNames []string, labelName string) []githubstructures.LabelStep
+	SplitLabelInEachRepo(repoNames []string, labelName string, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) []githubstructures.LabelStep
+	FindDrift(repoName string, steps []githubstructures.LabelStep) []string
+	RevertLabelSteps(repoName string, steps []githubstructures.LabelStep)
+	RecordSteps(stepRecorder func(step githubstructures.LabelStep))
 }
 
-func Up(githubOperator GitHubOperator, repoNames []string) {
-	up_2020_07_13_issue_type(githubOperator, repoNames)
-	log.Println("all migrations finished")
+type Ledger interface {
+	Record(id string) MigrationRecord
+	AddStep(id string, repoName string, step githubstructures.LabelStep) error
+	MarkRepo(id string, repoName string, at time.Time, steps []githubstructures.LabelStep) error
+	MarkApplied(id string, at time.Time) error
+	UnmarkRepo(id string, repoName string) error
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Migration struct {
+	Id string
+	Up func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep
+}
+
+var builtinMigrations = []Migration{
+	Migration{Id: "2020-07-13-issue-type", Up: up_2020_07_13_issue_type},
+}
+
+func Status(ledger Ledger, migrations []Migration) []MigrationRecord {
+	records := []MigrationRecord{}
+	for i := 0; i < len(migrations); i++ {
+		records = append(records, ledger.Record(migrations[i].Id))
+	}
+	return records
+}
+
+func migrationsUpTo(migrations []Migration, toId string) ([]Migration, error) {
+	if toId == "" {
+		return migrations, nil
+	}
+	for i := 0; i < len(migrations); i++ {
+		if migrations[i].Id == toId {
+			return migrations[:i+1], nil
+		}
+	}
+	return nil, errors.New("unknown migration \"" + toId + "\"")
+}
+
+func Up(githubOperator GitHubOperator, ledger Ledger, clock Clock, allMigrations []Migration, repoNames []string, toId string, isPartial bool) error {
+	migrations, err := migrationsUpTo(allMigrations, toId)
+	if err != nil {
+		return err
+	}
+	defer githubOperator.RecordSteps(nil)
+	for i := 0; i < len(migrations); i++ {
+		migration := migrations[i]
+		record := ledger.Record(migration.Id)
+		if record.AppliedAt != nil {
+			logging.Info(logging.Fields{Rule: "migration " + migration.Id}, "migration already applied at", *record.AppliedAt)
+			continue
+		}
+		for j := 0; j < len(repoNames); j++ {
+			repoName := repoNames[j]
+			if _, ok := record.Repos[repoName]; ok {
+				logging.Info(logging.Fields{Repo: repoName, Rule: "migration " + migration.Id}, "migration already applied")
+				continue
+			}
+			githubOperator.RecordSteps(func(step githubstructures.LabelStep) {
+				err := ledger.AddStep(migration.Id, repoName, step)
+				if err != nil {
+					panic(err)
+				}
+			})
+			steps := migration.Up(githubOperator, []string{repoName})
+			err = ledger.MarkRepo(migration.Id, repoName, clock.Now(), append(append([]githubstructures.LabelStep{}, record.Steps[repoName]...), steps...))
+			if err != nil {
+				return err
+			}
+		}
+		if isPartial {
+			logging.Info(logging.Fields{Rule: "migration " + migration.Id}, "migration finished for the selected repos")
+			continue
+		}
+		err = ledger.MarkApplied(migration.Id, clock.Now())
+		if err != nil {
+			return err
+		}
+		logging.Info(logging.Fields{Rule: "migration " + migration.Id}, "migration finished")
+	}
+	logging.Info(logging.Fields{}, "all migrations finished")
+	return nil
+}
+
+func Down(githubOperator GitHubOperator, ledger Ledger, id string) error {
+	record := ledger.Record(id)
+	if len(record.Repos) == 0 && len(record.Steps) == 0 {
+		return errors.New("migration \"" + id + "\" is not applied")
+	}
+	repoNames := []string{}
+	for repoName := range record.Repos {
+		repoNames = append(repoNames, repoName)
+	}
+	for repoName := range record.Steps {
+		if _, ok := record.Repos[repoName]; !ok {
+			repoNames = append(repoNames, repoName)
+		}
+	}
+	sort.Strings(repoNames)
+	drifts := []string{}
+	for i := 0; i < len(repoNames); i++ {
+		steps, ok := record.Steps[repoNames[i]]
+		if !ok {
+			drifts = append(drifts, repoNames[i]+": no recorded operations")
+			continue
+		}
+		drifts = append(drifts, githubOperator.FindDrift(repoNames[i], steps)...)
+	}
+	if len(drifts) > 0 {
+		return errors.New("migration \"" + id + "\" can't be rolled back because the state has drifted:\n" + strings.Join(drifts, "\n"))
+	}
+	for i := 0; i < len(repoNames); i++ {
+		githubOperator.RevertLabelSteps(repoNames[i], record.Steps[repoNames[i]])
+		err := ledger.UnmarkRepo(id, repoNames[i])
+		if err != nil {
+			return err
+		}
+	}
+	logging.Info(logging.Fields{Rule: "migration " + id}, "migration rolled back")
+	return nil
 }
@@ -0,0 +1,169 @@
+package migrations
+
+import (
+	"encoding/json"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/logging"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type MigrationRecord struct {
+	Id        string                                  `json:"id"`
+	AppliedAt *time.Time                              `json:"appliedAt,omitempty"`
+	Repos     map[string]time.Time                    `json:"repos"`
+	Steps     map[string][]githubstructures.LabelStep `json:"steps,omitempty"`
+}
+
+type ledger struct {
+	path    string
+	records []MigrationRecord
+}
+
+type LedgerJson struct {
+	Migrations []MigrationRecord `json:"migrations"`
+}
+
+func LoadLedger(path string) (*ledger, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ledger{path, []MigrationRecord{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ledgerJson := LedgerJson{}
+	err = json.Unmarshal(data, &ledgerJson)
+	if err != nil {
+		return nil, err
+	}
+	if ledgerJson.Migrations == nil {
+		ledgerJson.Migrations = []MigrationRecord{}
+	}
+	for i := 0; i < len(ledgerJson.Migrations); i++ {
+		if ledgerJson.Migrations[i].Steps == nil {
+			ledgerJson.Migrations[i].Steps = map[string][]githubstructures.LabelStep{}
+		}
+	}
+	return &ledger{path, ledgerJson.Migrations}, nil
+}
+
+func (ledger *ledger) save() error {
+	data, err := json.MarshalIndent(LedgerJson{Migrations: ledger.records}, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := filepath.Join(filepath.Dir(ledger.path), "."+filepath.Base(ledger.path)+".tmp")
+	err = ioutil.WriteFile(temporaryPath, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, ledger.path)
+}
+
+func (ledger *ledger) Record(id string) MigrationRecord {
+	for i := 0; i < len(ledger.records); i++ {
+		if ledger.records[i].Id == id {
+			return ledger.records[i]
+		}
+	}
+	return MigrationRecord{Id: id, Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{}}
+}
+
+func (ledger *ledger) update(record MigrationRecord) error {
+	i := 0
+	for ; i < len(ledger.records); i++ {
+		if ledger.records[i].Id == record.Id {
+			break
+		}
+	}
+	if i == len(ledger.records) {
+		ledger.records = append(ledger.records, record)
+	} else {
+		ledger.records[i] = record
+	}
+	return ledger.save()
+}
+
+func (ledger *ledger) AddStep(id string, repoName string, step githubstructures.LabelStep) error {
+	record := ledger.Record(id)
+	repoSteps := map[string][]githubstructures.LabelStep{}
+	for name, nameSteps := range record.Steps {
+		repoSteps[name] = nameSteps
+	}
+	repoSteps[repoName] = append(append([]githubstructures.LabelStep{}, record.Steps[repoName]...), step)
+	record.Steps = repoSteps
+	return ledger.update(record)
+}
+
+func (ledger *ledger) MarkRepo(id string, repoName string, at time.Time, steps []githubstructures.LabelStep) error {
+	record := ledger.Record(id)
+	repos := map[string]time.Time{repoName: at}
+	for name, appliedAt := range record.Repos {
+		if name != repoName {
+			repos[name] = appliedAt
+		}
+	}
+	repoSteps := map[string][]githubstructures.LabelStep{repoName: steps}
+	for name, nameSteps := range record.Steps {
+		if name != repoName {
+			repoSteps[name] = nameSteps
+		}
+	}
+	record.Repos = repos
+	record.Steps = repoSteps
+	return ledger.update(record)
+}
+
+func (ledger *ledger) UnmarkRepo(id string, repoName string) error {
+	record := ledger.Record(id)
+	repos := map[string]time.Time{}
+	for name, appliedAt := range record.Repos {
+		if name != repoName {
+			repos[name] = appliedAt
+		}
+	}
+	repoSteps := map[string][]githubstructures.LabelStep{}
+	for name, nameSteps := range record.Steps {
+		if name != repoName {
+			repoSteps[name] = nameSteps
+		}
+	}
+	record.AppliedAt = nil
+	record.Repos = repos
+	record.Steps = repoSteps
+	return ledger.update(record)
+}
+
+func (ledger *ledger) MarkApplied(id string, at time.Time) error {
+	record := ledger.Record(id)
+	record.AppliedAt = &at
+	return ledger.update(record)
+}
+
+type readOnlyLedger struct {
+	Ledger
+}
+
+func ReadOnly(ledger Ledger) Ledger {
+	return readOnlyLedger{ledger}
+}
+
+func (ledger readOnlyLedger) AddStep(id string, repoName string, step githubstructures.LabelStep) error {
+	return nil
+}
+
+func (ledger readOnlyLedger) MarkRepo(id string, repoName string, at time.Time, steps []githubstructures.LabelStep) error {
+	logging.Info(logging.Fields{Repo: repoName, Rule: "migration " + id}, "migration not recorded")
+	return nil
+}
+
+func (ledger readOnlyLedger) MarkApplied(id string, at time.Time) error {
+	return nil
+}
+
+func (ledger readOnlyLedger) UnmarkRepo(id string, repoName string) error {
+	return nil
+}
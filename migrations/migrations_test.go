@@ -0,0 +1,491 @@
+package migrations
+
+import (
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type Mockgithuboperator struct{}
+
+var mockCallsParams []interface{}
+var mockStepRecorder func(step githubstructures.LabelStep)
+
+func mockSteps(repoNames []string) []githubstructures.LabelStep {
+	step := githubstructures.LabelStep{RepoName: repoNames[0]}
+	if mockStepRecorder != nil {
+		mockStepRecorder(step)
+	}
+	return []githubstructures.LabelStep{step}
+}
+
+func (githubOperator Mockgithuboperator) RenameLabelInEachRepo(repoNames []string, oldLabelName string, newLabelName string) []githubstructures.LabelStep {
+	mockCallsParams = append(mockCallsParams, []interface{}{"rename", repoNames, oldLabelName, newLabelName})
+	return mockSteps(repoNames)
+}
+
+func (githubOperator Mockgithuboperator) MergeLabelInEachRepo(repoNames []string, sourceLabelName string, targetLabelName string) []githubstructures.LabelStep {
+	mockCallsParams = append(mockCallsParams, []interface{}{"merge", repoNames, sourceLabelName, targetLabelName})
+	return mockSteps(repoNames)
+}
+
+func (githubOperator Mockgithuboperator) RecolorLabelInEachRepo(repoNames []string, labelName string, color string) []githubstructures.LabelStep {
+	mockCallsParams = append(mockCallsParams, []interface{}{"recolor", repoNames, labelName, color})
+	return mockSteps(repoNames)
+}
+
+func (githubOperator Mockgithuboperator) DeleteUnusedLabelInEachRepo(repoNames []string, labelName string) []githubstructures.LabelStep {
+	mockCallsParams = append(mockCallsParams, []interface{}{"deleteIfUnused", repoNames, labelName})
+	return mockSteps(repoNames)
+}
+
+func (githubOperator Mockgithuboperator) SplitLabelInEachRepo(repoNames []string, labelName string, rules []githubstructures.LabelSplitRule, otherwiseLabelName string) []githubstructures.LabelStep {
+	mockCallsParams = append(mockCallsParams, []interface{}{"split", repoNames, labelName, rules, otherwiseLabelName})
+	return mockSteps(repoNames)
+}
+
+var mockFindDrift func(repoName string, steps []githubstructures.LabelStep) []string
+
+func (githubOperator Mockgithuboperator) FindDrift(repoName string, steps []githubstructures.LabelStep) []string {
+	return mockFindDrift(repoName, steps)
+}
+
+func (githubOperator Mockgithuboperator) RevertLabelSteps(repoName string, steps []githubstructures.LabelStep) {
+	mockCallsParams = append(mockCallsParams, []interface{}{"revert", repoName, steps})
+}
+
+func (githubOperator Mockgithuboperator) RecordSteps(stepRecorder func(step githubstructures.LabelStep)) {
+	mockStepRecorder = stepRecorder
+}
+
+type failingLedger struct {
+	Ledger
+}
+
+func (ledger failingLedger) MarkApplied(id string, at time.Time) error {
+	return errors.New("disk full")
+}
+
+type Mockclock struct{}
+
+func (clock Mockclock) Now() time.Time {
+	return time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+}
+
+func TestMigrations(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "migrations")
+}
+
+var _ = Describe("migrations", func() {
+	var dir string
+	var ledgerPath string
+	var allMigrations []Migration
+	var appliedParams []interface{}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "migrations")
+		Expect(err).NotTo(HaveOccurred())
+		ledgerPath = filepath.Join(dir, "ledger.json")
+		appliedParams = []interface{}{}
+		mockCallsParams = []interface{}{}
+		allMigrations = []Migration{
+			Migration{Id: "first", Up: func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+				appliedParams = append(appliedParams, []interface{}{"first", repoNames})
+				return []githubstructures.LabelStep{githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: repoNames[0], LabelName: "first"}}
+			}},
+			Migration{Id: "second", Up: func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+				appliedParams = append(appliedParams, []interface{}{"second", repoNames})
+				return []githubstructures.LabelStep{}
+			}},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("starts with an empty ledger", func() {
+		ledger, err := LoadLedger(ledgerPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Status(ledger, allMigrations)).To(Equal([]MigrationRecord{
+			MigrationRecord{Id: "first", Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{}},
+			MigrationRecord{Id: "second", Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{}},
+		}))
+	})
+
+	It("rejects an invalid ledger", func() {
+		Expect(ioutil.WriteFile(ledgerPath, []byte("{"), 0644)).To(Succeed())
+
+		_, err := LoadLedger(ledgerPath)
+
+		Expect(err).To(HaveOccurred())
+
+		_, err = LoadLedger(dir)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("loads a ledger without migrations", func() {
+		Expect(ioutil.WriteFile(ledgerPath, []byte("{}"), 0644)).To(Succeed())
+
+		ledger, err := LoadLedger(ledgerPath)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Status(ledger, allMigrations)[0]).To(Equal(MigrationRecord{Id: "first", Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{}}))
+	})
+
+	It("applies pending migrations once", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1", "repo-2"}, "", false)).To(Succeed())
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1", "repo-2"}, "", false)).To(Succeed())
+
+		Expect(appliedParams).To(Equal([]interface{}{
+			[]interface{}{"first", []string{"repo-1"}},
+			[]interface{}{"first", []string{"repo-2"}},
+			[]interface{}{"second", []string{"repo-1"}},
+			[]interface{}{"second", []string{"repo-2"}},
+		}))
+		reloadedLedger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		appliedAt := time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+		Expect(Status(reloadedLedger, allMigrations)).To(Equal([]MigrationRecord{
+			MigrationRecord{Id: "first", AppliedAt: &appliedAt, Repos: map[string]time.Time{"repo-1": appliedAt, "repo-2": appliedAt}, Steps: map[string][]githubstructures.LabelStep{
+				"repo-1": []githubstructures.LabelStep{githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "first"}},
+				"repo-2": []githubstructures.LabelStep{githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-2", LabelName: "first"}},
+			}},
+			MigrationRecord{Id: "second", AppliedAt: &appliedAt, Repos: map[string]time.Time{"repo-1": appliedAt, "repo-2": appliedAt}, Steps: map[string][]githubstructures.LabelStep{
+				"repo-1": []githubstructures.LabelStep{},
+				"repo-2": []githubstructures.LabelStep{},
+			}},
+		}))
+	})
+
+	It("applies migrations up to the given ID", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1"}, "first", false)).To(Succeed())
+
+		Expect(appliedParams).To(Equal([]interface{}{
+			[]interface{}{"first", []string{"repo-1"}},
+		}))
+		Expect(Status(ledger, allMigrations)[1].AppliedAt).To(BeNil())
+	})
+
+	It("resumes an interrupted migration", func() {
+		Expect(ioutil.WriteFile(ledgerPath, []byte(`{"migrations": [{"id": "first", "repos": {"repo-1": "2020-07-12T10:00:00Z"}}]}`), 0644)).To(Succeed())
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1", "repo-2"}, "first", false)).To(Succeed())
+
+		Expect(appliedParams).To(Equal([]interface{}{
+			[]interface{}{"first", []string{"repo-2"}},
+		}))
+		Expect(Status(ledger, allMigrations)[0].Repos).To(HaveLen(2))
+	})
+
+	It("doesn't mark migrations applied for some of the repos", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1"}, "", true)).To(Succeed())
+
+		Expect(appliedParams).To(HaveLen(2))
+		Expect(Status(ledger, allMigrations)[0].Repos).To(HaveLen(1))
+		Expect(Status(ledger, allMigrations)[0].AppliedAt).To(BeNil())
+		Expect(Status(ledger, allMigrations)[1].AppliedAt).To(BeNil())
+	})
+
+	It("doesn't record migrations in a read-only ledger", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Up(Mockgithuboperator{}, ReadOnly(ledger), Mockclock{}, allMigrations, []string{"repo-1"}, "", false)).To(Succeed())
+		Expect(ReadOnly(ledger).UnmarkRepo("first", "repo-1")).To(Succeed())
+		Expect(ReadOnly(ledger).AddStep("first", "repo-1", githubstructures.LabelStep{})).To(Succeed())
+
+		Expect(appliedParams).To(HaveLen(2))
+		Expect(Status(ledger, allMigrations)[0]).To(Equal(MigrationRecord{Id: "first", Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{}}))
+		_, err = os.Stat(ledgerPath)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("records the steps applied before a migration fails", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		failingMigrations := []Migration{
+			Migration{Id: "failing", Up: func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+				githubOperator.RenameLabelInEachRepo(repoNames, "bug", "type: bug")
+				panic("502 Bad Gateway")
+			}},
+		}
+
+		Expect(func() {
+			Up(Mockgithuboperator{}, ledger, Mockclock{}, failingMigrations, []string{"repo-1"}, "", false)
+		}).To(PanicWith("502 Bad Gateway"))
+
+		Expect(mockStepRecorder).To(BeNil())
+		reloadedLedger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Status(reloadedLedger, failingMigrations)).To(Equal([]MigrationRecord{
+			MigrationRecord{Id: "failing", Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{
+				"repo-1": []githubstructures.LabelStep{githubstructures.LabelStep{RepoName: "repo-1"}},
+			}},
+		}))
+		mockFindDrift = func(repoName string, steps []githubstructures.LabelStep) []string {
+			return []string{}
+		}
+		mockCallsParams = []interface{}{}
+
+		Expect(Down(Mockgithuboperator{}, reloadedLedger, "failing")).To(Succeed())
+
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"revert", "repo-1", []githubstructures.LabelStep{githubstructures.LabelStep{RepoName: "repo-1"}}},
+		}))
+		Expect(Status(reloadedLedger, failingMigrations)[0].Steps).To(BeEmpty())
+	})
+
+	It("resumes a failed migration with the steps already applied", func() {
+		Expect(ioutil.WriteFile(ledgerPath, []byte(`{"migrations": [{"id": "first", "repos": {}, "steps": {"repo-1": [{"Kind": 3, "RepoName": "repo-1", "LabelName": "bug"}]}}]}`), 0644)).To(Succeed())
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1"}, "first", false)).To(Succeed())
+
+		Expect(Status(ledger, allMigrations)[0].Steps["repo-1"]).To(Equal([]githubstructures.LabelStep{
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "bug"},
+			githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "first"},
+		}))
+	})
+
+	It("fails when a step can't be recorded", func() {
+		ledger, err := LoadLedger(filepath.Join(dir, "missing", "ledger.json"))
+		Expect(err).NotTo(HaveOccurred())
+		renamingMigrations := []Migration{
+			Migration{Id: "renaming", Up: func(githubOperator GitHubOperator, repoNames []string) []githubstructures.LabelStep {
+				return githubOperator.RenameLabelInEachRepo(repoNames, "bug", "type: bug")
+			}},
+		}
+
+		Expect(func() {
+			Up(Mockgithuboperator{}, ledger, Mockclock{}, renamingMigrations, []string{"repo-1"}, "", false)
+		}).To(Panic())
+	})
+
+	It("rejects an unknown migration", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1"}, "third", false)
+
+		Expect(err).To(MatchError("unknown migration \"third\""))
+		Expect(appliedParams).To(BeEmpty())
+	})
+
+	It("fails when the ledger can't be saved", func() {
+		ledger, err := LoadLedger(filepath.Join(dir, "missing", "ledger.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		err = Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1"}, "", false)
+
+		Expect(err).To(HaveOccurred())
+		Expect(ledger.AddStep("first", "repo-1", githubstructures.LabelStep{})).NotTo(Succeed())
+	})
+
+	It("fails when a migration can't be marked applied", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = Up(Mockgithuboperator{}, failingLedger{ledger}, Mockclock{}, allMigrations, []string{"repo-1"}, "", false)
+
+		Expect(err).To(MatchError("disk full"))
+		Expect(ledger.MarkApplied("first", time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))).NotTo(Succeed())
+	})
+
+	It("rolls back a migration", func() {
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Up(Mockgithuboperator{}, ledger, Mockclock{}, allMigrations, []string{"repo-1", "repo-2"}, "", false)).To(Succeed())
+		mockFindDrift = func(repoName string, steps []githubstructures.LabelStep) []string {
+			return []string{}
+		}
+
+		Expect(Down(Mockgithuboperator{}, ledger, "first")).To(Succeed())
+
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"revert", "repo-1", []githubstructures.LabelStep{githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-1", LabelName: "first"}}},
+			[]interface{}{"revert", "repo-2", []githubstructures.LabelStep{githubstructures.LabelStep{Kind: githubstructures.LabelStepKindEnum.DELETE_LABEL, RepoName: "repo-2", LabelName: "first"}}},
+		}))
+		reloadedLedger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(Status(reloadedLedger, allMigrations)[0]).To(Equal(MigrationRecord{Id: "first", Repos: map[string]time.Time{}, Steps: map[string][]githubstructures.LabelStep{}}))
+		Expect(Status(reloadedLedger, allMigrations)[1].AppliedAt).NotTo(BeNil())
+
+		err = Down(Mockgithuboperator{}, ledger, "first")
+
+		Expect(err).To(MatchError("migration \"first\" is not applied"))
+	})
+
+	It("refuses to roll back a migration when the state has drifted", func() {
+		Expect(ioutil.WriteFile(ledgerPath, []byte(`{"migrations": [{"id": "first", "repos": {"repo-1": "2020-07-12T10:00:00Z", "repo-2": "2020-07-12T10:00:00Z", "repo-3": "2020-07-12T10:00:00Z"}, "steps": {"repo-1": [], "repo-2": []}}]}`), 0644)).To(Succeed())
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		mockFindDrift = func(repoName string, steps []githubstructures.LabelStep) []string {
+			if repoName == "repo-2" {
+				return []string{"repo-2: label \"bug\" exists again"}
+			}
+			return []string{}
+		}
+
+		err = Down(Mockgithuboperator{}, ledger, "first")
+
+		Expect(err).To(MatchError("migration \"first\" can't be rolled back because the state has drifted:\nrepo-2: label \"bug\" exists again\nrepo-3: no recorded operations"))
+		Expect(mockCallsParams).To(BeEmpty())
+	})
+
+	It("fails to roll back when the ledger can't be saved", func() {
+		Expect(ioutil.WriteFile(ledgerPath, []byte(`{"migrations": [{"id": "first", "repos": {"repo-1": "2020-07-12T10:00:00Z"}, "steps": {"repo-1": []}}]}`), 0644)).To(Succeed())
+		ledger, err := LoadLedger(ledgerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Remove(ledgerPath)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(ledgerPath, "blocked"), 0755)).To(Succeed())
+		mockFindDrift = func(repoName string, steps []githubstructures.LabelStep) []string {
+			return []string{}
+		}
+
+		err = Down(Mockgithuboperator{}, ledger, "first")
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("renames the issue type labels", func() {
+		steps := up_2020_07_13_issue_type(Mockgithuboperator{}, []string{"repo-1"})
+
+		Expect(steps).To(HaveLen(3))
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"rename", []string{"repo-1"}, "bug", "type: bug"},
+			[]interface{}{"rename", []string{"repo-1"}, "enhancement", "type: enhancement"},
+			[]interface{}{"rename", []string{"repo-1"}, "question", "type: question"},
+		}))
+	})
+
+	It("parses declarative migrations", func() {
+		migration, err := ParseMigration("2021-01-01-cleanup", []byte(`
+operations:
+  - rename: {from: enhancement, to: "type: enhancement"}
+  - merge: {from: Bug, to: "type: bug"}
+  - recolor: {label: "type: bug", color: "#D73A4A"}
+  - deleteIfUnused: {label: wontfix}
+  - split:
+      label: triage
+      rules:
+        - label: "type: bug"
+          titleMatches: "(?i)crash"
+          hasLabel: blocked
+          pullRequest: false
+      otherwise: "type: question"
+`))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migration.Id).To(Equal("2021-01-01-cleanup"))
+		steps := migration.Up(Mockgithuboperator{}, []string{"repo-1"})
+		Expect(steps).To(HaveLen(5))
+		isPullRequest := false
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"rename", []string{"repo-1"}, "enhancement", "type: enhancement"},
+			[]interface{}{"merge", []string{"repo-1"}, "Bug", "type: bug"},
+			[]interface{}{"recolor", []string{"repo-1"}, "type: bug", "d73a4a"},
+			[]interface{}{"deleteIfUnused", []string{"repo-1"}, "wontfix"},
+			[]interface{}{"split", []string{"repo-1"}, "triage", []githubstructures.LabelSplitRule{
+				githubstructures.LabelSplitRule{LabelName: "type: bug", TitlePattern: regexp.MustCompile("(?i)crash"), HasLabelName: "blocked", IsPullRequest: &isPullRequest},
+			}, "type: question"},
+		}))
+	})
+
+	It("rejects invalid declarative migrations", func() {
+		invalidMigrations := map[string]string{
+			"operations: []":                                                    "migration \"invalid\": no operations",
+			"operations: [{}]":                                                  "migration \"invalid\": operation #1: expected exactly one of rename, merge, recolor, deleteIfUnused or split",
+			"operations: [{rename: {from: a}}]":                                 "migration \"invalid\": operation #1: rename: both from and to are required",
+			"operations: [{merge: {to: a}}]":                                    "migration \"invalid\": operation #1: merge: both from and to are required",
+			"operations: [{recolor: {label: a}}]":                               "migration \"invalid\": operation #1: recolor: both label and color are required",
+			"operations: [{recolor: {label: a, color: red}}]":                   "migration \"invalid\": operation #1: recolor: invalid color \"red\", expected 6 or 3 hex digits like \"d73a4a\" or \"#fff\"",
+			"operations: [{deleteIfUnused: {}}]":                                "migration \"invalid\": operation #1: deleteIfUnused: label is required",
+			"operations: [{split: {rules: [{label: a}]}}]":                      "migration \"invalid\": operation #1: split requires a label",
+			"operations: [{split: {label: a}}]":                                 "migration \"invalid\": operation #1: split requires rules or an otherwise label",
+			"operations: [{split: {label: a, rules: [{}]}}]":                    "migration \"invalid\": operation #1: split rule #1 requires a label",
+			"operations: [{rename: {from: a, to: b}, merge: {from: a, to: b}}]": "migration \"invalid\": operation #1: expected exactly one of rename, merge, recolor, deleteIfUnused or split",
+		}
+		for data, message := range invalidMigrations {
+			_, err := ParseMigration("invalid", []byte(data))
+
+			Expect(err).To(MatchError(message), data)
+		}
+		_, err := ParseMigration("invalid", []byte("operations: [{split: {label: a, rules: [{label: b, titleMatches: \"(\"}]}}]"))
+		Expect(err).To(MatchError(ContainSubstring("split rule #1: invalid titleMatches")))
+		_, err = ParseMigration("invalid", []byte("operations: [{drop: {label: a}}]"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("loads migrations from a directory together with the built-in ones", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "2021-01-01-cleanup.yml"), []byte("operations: [{deleteIfUnused: {label: wontfix}}]"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "2020-01-01-first.yaml"), []byte("operations: [{deleteIfUnused: {label: duplicate}}]"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a migration"), 0644)).To(Succeed())
+		Expect(os.Mkdir(filepath.Join(dir, "nested.yml"), 0755)).To(Succeed())
+
+		migrations, err := All(dir)
+
+		Expect(err).NotTo(HaveOccurred())
+		ids := []string{}
+		for i := 0; i < len(migrations); i++ {
+			ids = append(ids, migrations[i].Id)
+		}
+		Expect(ids).To(Equal([]string{"2020-01-01-first", "2020-07-13-issue-type", "2021-01-01-cleanup"}))
+	})
+
+	It("loads only the built-in migrations without a directory", func() {
+		migrations, err := All(filepath.Join(dir, "missing"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migrations).To(HaveLen(1))
+	})
+
+	It("rejects invalid and duplicated migrations in a directory", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "2020-07-13-issue-type.yml"), []byte("operations: [{deleteIfUnused: {label: wontfix}}]"), 0644)).To(Succeed())
+
+		_, err := All(dir)
+
+		Expect(err).To(MatchError("duplicated migration \"2020-07-13-issue-type\""))
+
+		Expect(ioutil.WriteFile(filepath.Join(dir, "2021-01-01-invalid.yml"), []byte("operations: []"), 0644)).To(Succeed())
+
+		_, err = All(dir)
+
+		Expect(err).To(MatchError("migration \"2021-01-01-invalid\": no operations"))
+
+		_, err = All(filepath.Join(dir, "2021-01-01-invalid.yml"))
+
+		Expect(err).To(HaveOccurred())
+
+		Expect(os.Remove(filepath.Join(dir, "2021-01-01-invalid.yml"))).To(Succeed())
+		Expect(os.Remove(filepath.Join(dir, "2020-07-13-issue-type.yml"))).To(Succeed())
+		Expect(os.Symlink(filepath.Join(dir, "missing.yml"), filepath.Join(dir, "broken.yml"))).To(Succeed())
+
+		_, err = All(dir)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,121 @@
+package pruneplan
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type MergeYaml struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	Uses int    `yaml:"uses"`
+}
+
+type RepoPlanYaml struct {
+	Repo   string      `yaml:"repo"`
+	Delete []string    `yaml:"delete"`
+	Merge  []MergeYaml `yaml:"merge"`
+}
+
+type PlanYaml struct {
+	Repos []RepoPlanYaml `yaml:"repos"`
+}
+
+func Export(plans []githubstructures.LabelPrunePlan) ([]byte, error) {
+	planYaml := PlanYaml{Repos: []RepoPlanYaml{}}
+	for i := 0; i < len(plans); i++ {
+		plan := plans[i]
+		if len(plan.LabelsToDelete) == 0 && len(plan.LabelsToMerge) == 0 {
+			continue
+		}
+		repoPlanYaml := RepoPlanYaml{Repo: plan.RepoName, Delete: []string{}, Merge: []MergeYaml{}}
+		for j := 0; j < len(plan.LabelsToDelete); j++ {
+			repoPlanYaml.Delete = append(repoPlanYaml.Delete, plan.LabelsToDelete[j].Label.Name)
+		}
+		for j := 0; j < len(plan.LabelsToMerge); j++ {
+			merge := plan.LabelsToMerge[j]
+			repoPlanYaml.Merge = append(repoPlanYaml.Merge, MergeYaml{From: merge.Source.Label.Name, To: merge.TargetLabelName, Uses: merge.Source.UsesCount})
+		}
+		planYaml.Repos = append(planYaml.Repos, repoPlanYaml)
+	}
+	return yaml.Marshal(planYaml)
+}
+
+func Parse(data []byte) ([]githubstructures.LabelPrunePlan, error) {
+	planYaml := PlanYaml{}
+	err := yaml.UnmarshalStrict(data, &planYaml)
+	if err != nil {
+		return nil, err
+	}
+	plans := []githubstructures.LabelPrunePlan{}
+	for i := 0; i < len(planYaml.Repos); i++ {
+		repoPlanYaml := planYaml.Repos[i]
+		if repoPlanYaml.Repo == "" {
+			return nil, errors.New("repo #" + strconv.Itoa(i+1) + ": repo is required")
+		}
+		plan := githubstructures.LabelPrunePlan{RepoName: repoPlanYaml.Repo, LabelsToDelete: []githubstructures.LabelUsage{}, LabelsToMerge: []githubstructures.LabelMergeProposal{}}
+		for j := 0; j < len(repoPlanYaml.Delete); j++ {
+			if repoPlanYaml.Delete[j] == "" {
+				return nil, errors.New(repoPlanYaml.Repo + ": delete #" + strconv.Itoa(j+1) + ": label is required")
+			}
+			plan.LabelsToDelete = append(plan.LabelsToDelete, githubstructures.LabelUsage{Label: githubstructures.Label{Name: repoPlanYaml.Delete[j]}})
+		}
+		for j := 0; j < len(repoPlanYaml.Merge); j++ {
+			mergeYaml := repoPlanYaml.Merge[j]
+			if mergeYaml.From == "" || mergeYaml.To == "" {
+				return nil, errors.New(repoPlanYaml.Repo + ": merge #" + strconv.Itoa(j+1) + ": both from and to are required")
+			}
+			plan.LabelsToMerge = append(plan.LabelsToMerge, githubstructures.LabelMergeProposal{
+				Source:          githubstructures.LabelUsage{Label: githubstructures.Label{Name: mergeYaml.From}, UsesCount: mergeYaml.Uses},
+				TargetLabelName: mergeYaml.To,
+			})
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func Load(path string) ([]githubstructures.LabelPrunePlan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Review(plans []githubstructures.LabelPrunePlan, reader io.Reader, writer io.Writer) []githubstructures.LabelPrunePlan {
+	scanner := bufio.NewScanner(reader)
+	confirm := func(question string) bool {
+		fmt.Fprint(writer, question+" [y/N] ")
+		if !scanner.Scan() {
+			return false
+		}
+		answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		return answer == "y" || answer == "yes"
+	}
+	reviewedPlans := []githubstructures.LabelPrunePlan{}
+	for i := 0; i < len(plans); i++ {
+		plan := plans[i]
+		reviewedPlan := githubstructures.LabelPrunePlan{RepoName: plan.RepoName, LabelsToDelete: []githubstructures.LabelUsage{}, LabelsToMerge: []githubstructures.LabelMergeProposal{}}
+		for j := 0; j < len(plan.LabelsToMerge); j++ {
+			merge := plan.LabelsToMerge[j]
+			if confirm(plan.RepoName + ": merge " + strconv.Quote(merge.Source.Label.Name) + " (" + strconv.Itoa(merge.Source.UsesCount) + " uses) into " + strconv.Quote(merge.TargetLabelName) + "?") {
+				reviewedPlan.LabelsToMerge = append(reviewedPlan.LabelsToMerge, merge)
+			}
+		}
+		for j := 0; j < len(plan.LabelsToDelete); j++ {
+			if confirm(plan.RepoName + ": delete unused " + strconv.Quote(plan.LabelsToDelete[j].Label.Name) + "?") {
+				reviewedPlan.LabelsToDelete = append(reviewedPlan.LabelsToDelete, plan.LabelsToDelete[j])
+			}
+		}
+		reviewedPlans = append(reviewedPlans, reviewedPlan)
+	}
+	return reviewedPlans
+}
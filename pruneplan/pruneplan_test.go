@@ -0,0 +1,115 @@
+package pruneplan
+
+import (
+	"bytes"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPruneplan(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "pruneplan")
+}
+
+var _ = Describe("pruneplan", func() {
+	plans := []githubstructures.LabelPrunePlan{
+		githubstructures.LabelPrunePlan{
+			RepoName:       "repo-1",
+			LabelsToDelete: []githubstructures.LabelUsage{githubstructures.LabelUsage{Label: githubstructures.Label{Name: "wontfix"}}, githubstructures.LabelUsage{Label: githubstructures.Label{Name: "docs"}}},
+			LabelsToMerge: []githubstructures.LabelMergeProposal{
+				githubstructures.LabelMergeProposal{Source: githubstructures.LabelUsage{Label: githubstructures.Label{Name: "Bug"}, UsesCount: 3}, TargetLabelName: "type: bug"},
+			},
+		},
+		githubstructures.LabelPrunePlan{
+			RepoName:       "repo-2",
+			LabelsToDelete: []githubstructures.LabelUsage{},
+			LabelsToMerge:  []githubstructures.LabelMergeProposal{},
+		},
+	}
+
+	It("exports and parses a plan", func() {
+		data, err := Export(plans)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`repos:
+- repo: repo-1
+  delete:
+  - wontfix
+  - docs
+  merge:
+  - from: Bug
+    to: 'type: bug'
+    uses: 3
+`))
+
+		parsedPlans, err := Parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsedPlans).To(Equal(plans[:1]))
+	})
+
+	It("loads a plan from a file", func() {
+		dir, err := ioutil.TempDir("", "pruneplan")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "plan.yml")
+		Expect(ioutil.WriteFile(path, []byte("repos: [{repo: repo-1, delete: [wontfix]}]"), 0644)).To(Succeed())
+
+		loadedPlans, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loadedPlans).To(Equal([]githubstructures.LabelPrunePlan{
+			githubstructures.LabelPrunePlan{
+				RepoName:       "repo-1",
+				LabelsToDelete: []githubstructures.LabelUsage{githubstructures.LabelUsage{Label: githubstructures.Label{Name: "wontfix"}}},
+				LabelsToMerge:  []githubstructures.LabelMergeProposal{},
+			},
+		}))
+
+		_, err = Load(filepath.Join(dir, "missing.yml"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid plans", func() {
+		_, err := Parse([]byte("repos: [{repo: repo-1, remove: [wontfix]}]"))
+		Expect(err).To(HaveOccurred())
+
+		_, err = Parse([]byte("repos: [{delete: [wontfix]}]"))
+		Expect(err).To(MatchError("repo #1: repo is required"))
+
+		_, err = Parse([]byte("repos: [{repo: repo-1, delete: ['']}]"))
+		Expect(err).To(MatchError("repo-1: delete #1: label is required"))
+
+		_, err = Parse([]byte("repos: [{repo: repo-1, merge: [{from: Bug}]}]"))
+		Expect(err).To(MatchError("repo-1: merge #1: both from and to are required"))
+	})
+
+	It("reviews a plan interactively", func() {
+		output := bytes.Buffer{}
+
+		reviewedPlans := Review(plans, strings.NewReader("y\nno\n Yes \n"), &output)
+
+		Expect(output.String()).To(Equal("" +
+			"repo-1: merge \"Bug\" (3 uses) into \"type: bug\"? [y/N] " +
+			"repo-1: delete unused \"wontfix\"? [y/N] " +
+			"repo-1: delete unused \"docs\"? [y/N] "))
+		Expect(reviewedPlans).To(Equal([]githubstructures.LabelPrunePlan{
+			githubstructures.LabelPrunePlan{
+				RepoName:       "repo-1",
+				LabelsToDelete: []githubstructures.LabelUsage{githubstructures.LabelUsage{Label: githubstructures.Label{Name: "docs"}}},
+				LabelsToMerge:  plans[0].LabelsToMerge,
+			},
+			plans[1],
+		}))
+	})
+
+	It("declines the rest of the plan at the end of the input", func() {
+		reviewedPlans := Review(plans, strings.NewReader("y\n"), &bytes.Buffer{})
+
+		Expect(reviewedPlans[0].LabelsToMerge).To(HaveLen(1))
+		Expect(reviewedPlans[0].LabelsToDelete).To(BeEmpty())
+	})
+})
@@ -0,0 +1,78 @@
+package repofilter
+
+import (
+	"errors"
+	"path"
+)
+
+type repofilter struct {
+	repoNames []string
+	includes  []string
+	excludes  []string
+}
+
+func validatePatterns(patterns []string) error {
+	for i := 0; i < len(patterns); i++ {
+		_, err := path.Match(patterns[i], "")
+		if err != nil {
+			return errors.New("invalid repo pattern \"" + patterns[i] + "\": " + err.Error())
+		}
+	}
+	return nil
+}
+
+func New(repoNames []string, includes []string, excludes []string) (*repofilter, error) {
+	err := validatePatterns(includes)
+	if err != nil {
+		return nil, err
+	}
+	err = validatePatterns(excludes)
+	if err != nil {
+		return nil, err
+	}
+	repoFilter := &repofilter{repoNames, includes, excludes}
+	return repoFilter, nil
+}
+
+func matchesAny(patterns []string, repoName string) bool {
+	for i := 0; i < len(patterns); i++ {
+		if ok, _ := path.Match(patterns[i], repoName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(repoNames []string, repoName string) bool {
+	for i := 0; i < len(repoNames); i++ {
+		if repoNames[i] == repoName {
+			return true
+		}
+	}
+	return false
+}
+
+func (repoFilter repofilter) IsPartial() bool {
+	return len(repoFilter.repoNames) > 0 || len(repoFilter.includes) > 0 || len(repoFilter.excludes) > 0
+}
+
+func (repoFilter repofilter) Select(allRepoNames []string) ([]string, error) {
+	for i := 0; i < len(repoFilter.repoNames); i++ {
+		if !contains(allRepoNames, repoFilter.repoNames[i]) {
+			return nil, errors.New("unknown repo \"" + repoFilter.repoNames[i] + "\"")
+		}
+	}
+	isSelectingAll := len(repoFilter.repoNames) == 0 && len(repoFilter.includes) == 0
+	repoNames := []string{}
+	for i := 0; i < len(allRepoNames); i++ {
+		repoName := allRepoNames[i]
+		if !isSelectingAll && !contains(repoFilter.repoNames, repoName) && !matchesAny(repoFilter.includes, repoName) {
+			continue
+		}
+		if matchesAny(repoFilter.excludes, repoName) {
+			continue
+		}
+		repoNames = append(repoNames, repoName)
+	}
+	return repoNames, nil
+}
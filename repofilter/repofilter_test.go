@@ -0,0 +1,58 @@
+package repofilter
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestRepofilter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "repofilter")
+}
+
+var _ = Describe("repofilter", func() {
+	allRepoNames := []string{"api", "api-docs", "web", "web-legacy", "mobile"}
+
+	DescribeTable("Select",
+		func(repoNames []string, includes []string, excludes []string, expectedRepoNames []string) {
+			repoFilter, err := New(repoNames, includes, excludes)
+			Expect(err).NotTo(HaveOccurred())
+			selectedRepoNames, err := repoFilter.Select(allRepoNames)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selectedRepoNames).To(Equal(expectedRepoNames))
+		},
+		Entry("no filters", []string{}, []string{}, []string{}, allRepoNames),
+		Entry("explicit repos", []string{"mobile", "api"}, []string{}, []string{}, []string{"api", "mobile"}),
+		Entry("include pattern", []string{}, []string{"web*"}, []string{}, []string{"web", "web-legacy"}),
+		Entry("explicit repos and include pattern", []string{"mobile"}, []string{"api-*"}, []string{}, []string{"api-docs", "mobile"}),
+		Entry("exclude pattern", []string{}, []string{}, []string{"*-legacy", "*-docs"}, []string{"api", "web", "mobile"}),
+		Entry("include and exclude patterns", []string{}, []string{"web*"}, []string{"*-legacy"}, []string{"web"}),
+		Entry("exclude wins over explicit repos", []string{"web-legacy"}, []string{}, []string{"*-legacy"}, []string{}),
+		Entry("single character pattern", []string{}, []string{"we?"}, []string{}, []string{"web"}),
+	)
+
+	It("fails on unknown repos", func() {
+		repoFilter, err := New([]string{"api", "desktop"}, []string{}, []string{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = repoFilter.Select(allRepoNames)
+		Expect(err).To(MatchError("unknown repo \"desktop\""))
+	})
+
+	It("fails on invalid patterns", func() {
+		_, err := New([]string{}, []string{"web["}, []string{})
+		Expect(err).To(MatchError("invalid repo pattern \"web[\": syntax error in pattern"))
+		_, err = New([]string{}, []string{}, []string{"[-"})
+		Expect(err).To(MatchError("invalid repo pattern \"[-\": syntax error in pattern"))
+	})
+
+	It("tells whether repos are filtered", func() {
+		repoFilter, _ := New([]string{}, []string{}, []string{})
+		Expect(repoFilter.IsPartial()).To(BeFalse())
+		repoFilter, _ = New([]string{"api"}, []string{}, []string{})
+		Expect(repoFilter.IsPartial()).To(BeTrue())
+		repoFilter, _ = New([]string{}, []string{}, []string{"web*"})
+		Expect(repoFilter.IsPartial()).To(BeTrue())
+	})
+})
@@ -0,0 +1,172 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/labelcolor"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+func escapeMarkdown(cell string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(cell, "|", "\\|"), "\n", " ")
+}
+
+func (table Table) Markdown() string {
+	builder := strings.Builder{}
+	separators := []string{}
+	for i := 0; i < len(table.Header); i++ {
+		separators = append(separators, "---")
+	}
+	rows := append([][]string{table.Header, separators}, table.Rows...)
+	for i := 0; i < len(rows); i++ {
+		cells := []string{}
+		for j := 0; j < len(rows[i]); j++ {
+			cells = append(cells, escapeMarkdown(rows[i][j]))
+		}
+		builder.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return builder.String()
+}
+
+func (table Table) CSV() (string, error) {
+	buffer := bytes.Buffer{}
+	writer := csv.NewWriter(&buffer)
+	err := writer.WriteAll(append([][]string{table.Header}, table.Rows...))
+	return buffer.String(), err
+}
+
+func Format(format string, table Table, data interface{}) (string, error) {
+	switch format {
+	case "markdown":
+		return table.Markdown(), nil
+	case "csv":
+		return table.CSV()
+	case "json":
+		jsonData, err := json.MarshalIndent(data, "", "  ")
+		return string(jsonData) + "\n", err
+	}
+	return "", errors.New("unknown format \"" + format + "\", expected markdown, csv or json")
+}
+
+func labelNames(labels []githubstructures.Label) string {
+	names := []string{}
+	for i := 0; i < len(labels); i++ {
+		names = append(names, labels[i].Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func describeMismatch(mismatch githubstructures.LabelMismatch) string {
+	differences := []string{}
+	if !labelcolor.Same(mismatch.Expected.Color, mismatch.Actual.Color) {
+		differences = append(differences, "color "+mismatch.Actual.Color+" instead of "+mismatch.Expected.Color)
+	}
+	if mismatch.Expected.Description != mismatch.Actual.Description {
+		differences = append(differences, "description "+strconv.Quote(mismatch.Actual.Description)+" instead of "+strconv.Quote(mismatch.Expected.Description))
+	}
+	return mismatch.Actual.Name + " (" + strings.Join(differences, ", ") + ")"
+}
+
+func LabelAuditTable(audits []githubstructures.LabelAudit) Table {
+	table := Table{
+		Header: []string{"repo", "missing", "wrong color or description", "extra", "near-duplicates"},
+		Rows:   [][]string{},
+	}
+	for i := 0; i < len(audits); i++ {
+		audit := audits[i]
+		mismatches := []string{}
+		for j := 0; j < len(audit.MismatchedLabels); j++ {
+			mismatches = append(mismatches, describeMismatch(audit.MismatchedLabels[j]))
+		}
+		nearDuplicates := []string{}
+		for j := 0; j < len(audit.NearDuplicates); j++ {
+			nearDuplicates = append(nearDuplicates, strings.Join(audit.NearDuplicates[j], " ~ "))
+		}
+		table.Rows = append(table.Rows, []string{
+			audit.RepoName,
+			labelNames(audit.MissingLabels),
+			strings.Join(mismatches, ", "),
+			labelNames(audit.ExtraLabels),
+			strings.Join(nearDuplicates, ", "),
+		})
+	}
+	return table
+}
+
+func RepoReportTable(reports []githubstructures.RepoReport) Table {
+	table := Table{
+		Header: []string{"repo", "open issues", "ours", "answered", "not answered"},
+		Rows:   [][]string{},
+	}
+	for i := 0; i < len(reports); i++ {
+		report := reports[i]
+		row := []string{
+			report.RepoName,
+			strconv.Itoa(report.OpenIssuesCount),
+			strconv.Itoa(report.OursCount),
+			strconv.Itoa(report.AnsweredCount),
+			strconv.Itoa(report.NotAnsweredCount),
+		}
+		for j := 0; j < len(report.MissingLabelCounts); j++ {
+			if i == 0 {
+				table.Header = append(table.Header, "missing "+report.MissingLabelCounts[j].Prefix)
+			}
+			row = append(row, strconv.Itoa(report.MissingLabelCounts[j].Count))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+func RunSummaryTable(summary githubstructures.RunSummary) Table {
+	table := Table{
+		Header: []string{"repo", "open issues", "ours", "answered", "not answered"},
+		Rows:   [][]string{},
+	}
+	prefixes := []string{}
+	for i := 0; i < len(summary.Repos) && len(prefixes) == 0; i++ {
+		for j := 0; j < len(summary.Repos[i].Report.MissingLabelCounts); j++ {
+			prefixes = append(prefixes, summary.Repos[i].Report.MissingLabelCounts[j].Prefix)
+			table.Header = append(table.Header, "missing "+summary.Repos[i].Report.MissingLabelCounts[j].Prefix)
+		}
+	}
+	table.Header = append(table.Header, "labels created", "labels updated", "labels deleted", "issue label changes", "orphaned labels removed", "requests", "errors", "duration")
+	for i := 0; i < len(summary.Repos); i++ {
+		repoSummary := summary.Repos[i]
+		row := []string{
+			repoSummary.RepoName,
+			strconv.Itoa(repoSummary.Report.OpenIssuesCount),
+			strconv.Itoa(repoSummary.Report.OursCount),
+			strconv.Itoa(repoSummary.Report.AnsweredCount),
+			strconv.Itoa(repoSummary.Report.NotAnsweredCount),
+		}
+		for j := 0; j < len(prefixes); j++ {
+			count := ""
+			if j < len(repoSummary.Report.MissingLabelCounts) {
+				count = strconv.Itoa(repoSummary.Report.MissingLabelCounts[j].Count)
+			}
+			row = append(row, count)
+		}
+		table.Rows = append(table.Rows, append(row,
+			strconv.Itoa(repoSummary.LabelsCreatedCount),
+			strconv.Itoa(repoSummary.LabelsUpdatedCount),
+			strconv.Itoa(repoSummary.LabelsDeletedCount),
+			strconv.Itoa(repoSummary.IssueLabelChangesCount),
+			strconv.Itoa(len(repoSummary.OrphanedLabelRemovals)),
+			strconv.Itoa(repoSummary.RequestsCount),
+			strconv.Itoa(repoSummary.ErrorsCount),
+			repoSummary.Duration.Round(time.Millisecond).String(),
+		))
+	}
+	return table
+}
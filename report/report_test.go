@@ -0,0 +1,140 @@
+package report
+
+import (
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestReport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "report")
+}
+
+var _ = Describe("report", func() {
+	audits := []githubstructures.LabelAudit{
+		githubstructures.LabelAudit{
+			RepoName:      "repo-1",
+			MissingLabels: []githubstructures.Label{githubstructures.Label{Name: "stale"}, githubstructures.Label{Name: "overdue"}},
+			MismatchedLabels: []githubstructures.LabelMismatch{
+				githubstructures.LabelMismatch{
+					Expected: githubstructures.Label{Name: "wip", Color: "a0a000", Description: "Work in progress"},
+					Actual:   githubstructures.Label{Name: "WIP", Color: "ff0000"},
+				},
+				githubstructures.LabelMismatch{
+					Expected: githubstructures.Label{Name: "blocked", Color: "000000"},
+					Actual:   githubstructures.Label{Name: "blocked", Color: "000000", Description: "a|b"},
+				},
+			},
+			ExtraLabels:    []githubstructures.Label{githubstructures.Label{Name: "Bug"}, githubstructures.Label{Name: "type: bug"}},
+			NearDuplicates: [][]string{[]string{"Bug", "type: bug"}},
+		},
+		githubstructures.LabelAudit{
+			RepoName:         "repo-2",
+			MissingLabels:    []githubstructures.Label{},
+			MismatchedLabels: []githubstructures.LabelMismatch{},
+			ExtraLabels:      []githubstructures.Label{},
+			NearDuplicates:   [][]string{},
+		},
+	}
+
+	It("formats the label audit as Markdown", func() {
+		output, err := Format("markdown", LabelAuditTable(audits), audits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("" +
+			"| repo | missing | wrong color or description | extra | near-duplicates |\n" +
+			"| --- | --- | --- | --- | --- |\n" +
+			"| repo-1 | stale, overdue | WIP (color ff0000 instead of a0a000, description \"\" instead of \"Work in progress\"), blocked (description \"a\\|b\" instead of \"\") | Bug, type: bug | Bug ~ type: bug |\n" +
+			"| repo-2 |  |  |  |  |\n"))
+	})
+
+	It("formats the label audit as CSV", func() {
+		output, err := Format("csv", LabelAuditTable(audits), audits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("" +
+			"repo,missing,wrong color or description,extra,near-duplicates\n" +
+			"repo-1,\"stale, overdue\",\"WIP (color ff0000 instead of a0a000, description \"\"\"\" instead of \"\"Work in progress\"\"), blocked (description \"\"a|b\"\" instead of \"\"\"\")\",\"Bug, type: bug\",Bug ~ type: bug\n" +
+			"repo-2,,,,\n"))
+	})
+
+	It("formats the label audit as JSON", func() {
+		output, err := Format("json", LabelAuditTable(audits), audits)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(MatchJSON(`[
+			{
+				"repoName": "repo-1",
+				"missingLabels": [{"name": "stale", "color": "", "description": ""}, {"name": "overdue", "color": "", "description": ""}],
+				"mismatchedLabels": [
+					{"expected": {"name": "wip", "color": "a0a000", "description": "Work in progress"}, "actual": {"name": "WIP", "color": "ff0000", "description": ""}},
+					{"expected": {"name": "blocked", "color": "000000", "description": ""}, "actual": {"name": "blocked", "color": "000000", "description": "a|b"}}
+				],
+				"extraLabels": [{"name": "Bug", "color": "", "description": ""}, {"name": "type: bug", "color": "", "description": ""}],
+				"nearDuplicates": [["Bug", "type: bug"]]
+			},
+			{"repoName": "repo-2", "missingLabels": [], "mismatchedLabels": [], "extraLabels": [], "nearDuplicates": []}
+		]`))
+	})
+
+	It("formats the repo report as Markdown", func() {
+		reports := []githubstructures.RepoReport{
+			githubstructures.RepoReport{RepoName: "repo-1", OpenIssuesCount: 3, OursCount: 1, NotAnsweredCount: 2, MissingLabelCounts: []githubstructures.MissingLabelCount{
+				githubstructures.MissingLabelCount{Prefix: "type", Count: 0},
+				githubstructures.MissingLabelCount{Prefix: "severity", Count: 1},
+			}},
+			githubstructures.RepoReport{RepoName: "repo-2", MissingLabelCounts: []githubstructures.MissingLabelCount{
+				githubstructures.MissingLabelCount{Prefix: "type", Count: 0},
+				githubstructures.MissingLabelCount{Prefix: "severity", Count: 0},
+			}},
+		}
+		output, err := Format("markdown", RepoReportTable(reports), reports)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("" +
+			"| repo | open issues | ours | answered | not answered | missing type | missing severity |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| repo-1 | 3 | 1 | 0 | 2 | 0 | 1 |\n" +
+			"| repo-2 | 0 | 0 | 0 | 0 | 0 | 0 |\n"))
+	})
+
+	It("formats the run summary as Markdown", func() {
+		summary := githubstructures.RunSummary{Repos: []githubstructures.RepoSummary{
+			githubstructures.RepoSummary{
+				RepoName:              "repo-1",
+				OrphanedLabelRemovals: []githubstructures.LabelChange{githubstructures.LabelChange{IssueUrl: "url-1", LabelName: "severity: minor"}},
+				Report: githubstructures.RepoReport{RepoName: "repo-1", OpenIssuesCount: 3, OursCount: 1, NotAnsweredCount: 2, MissingLabelCounts: []githubstructures.MissingLabelCount{
+					githubstructures.MissingLabelCount{Prefix: "type", Count: 1},
+				}},
+				LabelsCreatedCount:     2,
+				LabelsUpdatedCount:     1,
+				IssueLabelChangesCount: 4,
+				RequestsCount:          12,
+				Duration:               1234567890 * time.Nanosecond,
+			},
+			githubstructures.RepoSummary{
+				RepoName:      "repo-2",
+				RequestsCount: 1,
+				ErrorsCount:   1,
+				Duration:      300 * time.Millisecond,
+			},
+		}}
+		output, err := Format("markdown", RunSummaryTable(summary), summary)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("" +
+			"| repo | open issues | ours | answered | not answered | missing type | labels created | labels updated | labels deleted | issue label changes | orphaned labels removed | requests | errors | duration |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| repo-1 | 3 | 1 | 0 | 2 | 1 | 2 | 1 | 0 | 4 | 1 | 12 | 0 | 1.235s |\n" +
+			"| repo-2 | 0 | 0 | 0 | 0 |  | 0 | 0 | 0 | 0 | 0 | 1 | 1 | 300ms |\n"))
+		output, err = Format("json", RunSummaryTable(summary), summary)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(MatchJSON(`{"repos": [
+			{"repoName": "repo-1", "orphanedLabelRemovals": [{"issueUrl": "url-1", "labelName": "severity: minor"}], "report": {"repoName": "repo-1", "openIssuesCount": 3, "oursCount": 1, "answeredCount": 0, "notAnsweredCount": 2, "missingLabelCounts": [{"prefix": "type", "count": 1}]}, "labelsCreatedCount": 2, "labelsUpdatedCount": 1, "labelsDeletedCount": 0, "issueLabelChangesCount": 4, "requestsCount": 12, "errorsCount": 0, "duration": "1.235s"},
+			{"repoName": "repo-2", "orphanedLabelRemovals": null, "report": {"repoName": "", "openIssuesCount": 0, "oursCount": 0, "answeredCount": 0, "notAnsweredCount": 0, "missingLabelCounts": null}, "labelsCreatedCount": 0, "labelsUpdatedCount": 0, "labelsDeletedCount": 0, "issueLabelChangesCount": 0, "requestsCount": 1, "errorsCount": 1, "duration": "300ms"}
+		]}`))
+	})
+
+	It("rejects unknown formats", func() {
+		_, err := Format("html", Table{}, nil)
+		Expect(err).To(MatchError("unknown format \"html\", expected markdown, csv or json"))
+	})
+})
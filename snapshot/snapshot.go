@@ -0,0 +1,94 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/logging"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const Version = 1
+
+type GitHubOperator interface {
+	SnapshotRepo(repoName string) githubstructures.RepoSnapshot
+	RestoreRepo(repoSnapshot githubstructures.RepoSnapshot, isDryRun bool) []githubstructures.LabelStep
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Snapshot struct {
+	Version      int                             `json:"version"`
+	Organization string                          `json:"organization"`
+	CreatedAt    time.Time                       `json:"createdAt"`
+	Repos        []githubstructures.RepoSnapshot `json:"repos"`
+}
+
+func Take(githubOperator GitHubOperator, clock Clock, organization string, repoNames []string) Snapshot {
+	snapshot := Snapshot{Version: Version, Organization: organization, CreatedAt: clock.Now(), Repos: []githubstructures.RepoSnapshot{}}
+	for i := 0; i < len(repoNames); i++ {
+		repoSnapshot := githubOperator.SnapshotRepo(repoNames[i])
+		logging.Info(logging.Fields{Repo: repoNames[i]}, "snapshot", len(repoSnapshot.Labels), "labels", len(repoSnapshot.Issues), "issues and pull requests")
+		snapshot.Repos = append(snapshot.Repos, repoSnapshot)
+	}
+	return snapshot
+}
+
+func Write(path string, snapshot Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	err = ioutil.WriteFile(temporaryPath, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
+
+func Read(path string) (Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot := Snapshot{}
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if snapshot.Version != Version {
+		return Snapshot{}, errors.New("unsupported snapshot version " + strconv.Itoa(snapshot.Version) + ", expected " + strconv.Itoa(Version))
+	}
+	return snapshot, nil
+}
+
+func Restore(githubOperator GitHubOperator, snapshot Snapshot, repoNames []string, isDryRun bool) ([]githubstructures.LabelStep, error) {
+	repoSnapshots := snapshot.Repos
+	if len(repoNames) > 0 {
+		repoSnapshots = []githubstructures.RepoSnapshot{}
+		for i := 0; i < len(repoNames); i++ {
+			j := 0
+			for ; j < len(snapshot.Repos); j++ {
+				if snapshot.Repos[j].RepoName == repoNames[i] {
+					break
+				}
+			}
+			if j == len(snapshot.Repos) {
+				return nil, errors.New("repo \"" + repoNames[i] + "\" is not in the snapshot")
+			}
+			repoSnapshots = append(repoSnapshots, snapshot.Repos[j])
+		}
+	}
+	steps := []githubstructures.LabelStep{}
+	for i := 0; i < len(repoSnapshots); i++ {
+		steps = append(steps, githubOperator.RestoreRepo(repoSnapshots[i], isDryRun)...)
+	}
+	return steps, nil
+}
@@ -0,0 +1,126 @@
+package snapshot
+
+import (
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type Mockgithuboperator struct{}
+
+var mockCallsParams []interface{}
+
+func (githubOperator Mockgithuboperator) SnapshotRepo(repoName string) githubstructures.RepoSnapshot {
+	mockCallsParams = append(mockCallsParams, []interface{}{"snapshot", repoName})
+	return githubstructures.RepoSnapshot{
+		RepoName: repoName,
+		Labels:   []githubstructures.Label{githubstructures.Label{Name: "type: bug", Color: "d73a4a"}},
+		Issues:   []githubstructures.IssueSnapshot{githubstructures.IssueSnapshot{Number: 1, LabelNames: []string{"type: bug"}}},
+	}
+}
+
+func (githubOperator Mockgithuboperator) RestoreRepo(repoSnapshot githubstructures.RepoSnapshot, isDryRun bool) []githubstructures.LabelStep {
+	mockCallsParams = append(mockCallsParams, []interface{}{"restore", repoSnapshot.RepoName, isDryRun})
+	return []githubstructures.LabelStep{githubstructures.LabelStep{RepoName: repoSnapshot.RepoName}}
+}
+
+type Mockclock struct{}
+
+func (clock Mockclock) Now() time.Time {
+	return time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC)
+}
+
+func TestSnapshot(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "snapshot")
+}
+
+var _ = Describe("snapshot", func() {
+	var dir string
+	var path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "snapshot")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "snapshot.json")
+		mockCallsParams = []interface{}{}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("takes, writes and reads a snapshot", func() {
+		snapshot := Take(Mockgithuboperator{}, Mockclock{}, "brainhubeu", []string{"repo-1", "repo-2"})
+
+		Expect(snapshot.Version).To(Equal(1))
+		Expect(snapshot.Organization).To(Equal("brainhubeu"))
+		Expect(snapshot.CreatedAt).To(Equal(Mockclock{}.Now()))
+		Expect(snapshot.Repos).To(HaveLen(2))
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"snapshot", "repo-1"},
+			[]interface{}{"snapshot", "repo-2"},
+		}))
+
+		Expect(Write(path, snapshot)).To(Succeed())
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(MatchJSON(`{
+			"version": 1,
+			"organization": "brainhubeu",
+			"createdAt": "2020-07-13T10:00:00Z",
+			"repos": [
+				{"repoName": "repo-1", "labels": [{"name": "type: bug", "color": "d73a4a", "description": ""}], "issues": [{"number": 1, "isPullRequest": false, "labelNames": ["type: bug"]}]},
+				{"repoName": "repo-2", "labels": [{"name": "type: bug", "color": "d73a4a", "description": ""}], "issues": [{"number": 1, "isPullRequest": false, "labelNames": ["type: bug"]}]}
+			]
+		}`))
+		readSnapshot, err := Read(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readSnapshot).To(Equal(snapshot))
+	})
+
+	It("rejects invalid snapshots", func() {
+		_, err := Read(path)
+		Expect(err).To(HaveOccurred())
+
+		Expect(ioutil.WriteFile(path, []byte("{"), 0644)).To(Succeed())
+		_, err = Read(path)
+		Expect(err).To(HaveOccurred())
+
+		Expect(ioutil.WriteFile(path, []byte(`{"version": 2, "repos": []}`), 0644)).To(Succeed())
+		_, err = Read(path)
+		Expect(err).To(MatchError("unsupported snapshot version 2, expected 1"))
+	})
+
+	It("fails to write a snapshot into a missing directory or with an invalid time", func() {
+		Expect(Write(filepath.Join(dir, "missing", "snapshot.json"), Snapshot{})).NotTo(Succeed())
+		Expect(Write(path, Snapshot{CreatedAt: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)})).NotTo(Succeed())
+	})
+
+	It("restores every repo or the chosen ones", func() {
+		snapshot := Take(Mockgithuboperator{}, Mockclock{}, "brainhubeu", []string{"repo-1", "repo-2"})
+		mockCallsParams = []interface{}{}
+
+		steps, err := Restore(Mockgithuboperator{}, snapshot, []string{}, true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(steps).To(HaveLen(2))
+
+		steps, err = Restore(Mockgithuboperator{}, snapshot, []string{"repo-2"}, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(steps).To(Equal([]githubstructures.LabelStep{githubstructures.LabelStep{RepoName: "repo-2"}}))
+		Expect(mockCallsParams).To(Equal([]interface{}{
+			[]interface{}{"restore", "repo-1", true},
+			[]interface{}{"restore", "repo-2", true},
+			[]interface{}{"restore", "repo-2", false},
+		}))
+
+		_, err = Restore(Mockgithuboperator{}, snapshot, []string{"repo-3"}, false)
+		Expect(err).To(MatchError("repo \"repo-3\" is not in the snapshot"))
+	})
+})
@@ -0,0 +1,63 @@
+package taxonomy
+
+import (
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"github.com/brainhubeu/issue-overseer/labelcolor"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type LabelYaml struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type TaxonomyYaml struct {
+	Labels []LabelYaml `yaml:"labels"`
+}
+
+func Parse(data []byte) ([]githubstructures.Label, error) {
+	taxonomyYaml := TaxonomyYaml{}
+	err := yaml.UnmarshalStrict(data, &taxonomyYaml)
+	if err != nil {
+		return nil, err
+	}
+	labels := []githubstructures.Label{}
+	for i := 0; i < len(taxonomyYaml.Labels); i++ {
+		labelYaml := taxonomyYaml.Labels[i]
+		if labelYaml.Name == "" || labelYaml.Color == "" {
+			return nil, errors.New("label #" + strconv.Itoa(i+1) + ": both name and color are required")
+		}
+		color, err := labelcolor.Parse(labelYaml.Color)
+		if err != nil {
+			return nil, errors.New("label #" + strconv.Itoa(i+1) + ": " + err.Error())
+		}
+		for j := 0; j < len(labels); j++ {
+			if strings.EqualFold(labels[j].Name, labelYaml.Name) {
+				return nil, errors.New("label #" + strconv.Itoa(i+1) + ": duplicated label \"" + labelYaml.Name + "\"")
+			}
+		}
+		labels = append(labels, githubstructures.Label{Name: labelYaml.Name, Color: color, Description: labelYaml.Description})
+	}
+	return labels, nil
+}
+
+func Load(path string) ([]githubstructures.Label, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Export(labels []githubstructures.Label) ([]byte, error) {
+	taxonomyYaml := TaxonomyYaml{Labels: []LabelYaml{}}
+	for i := 0; i < len(labels); i++ {
+		taxonomyYaml.Labels = append(taxonomyYaml.Labels, LabelYaml{Name: labels[i].Name, Color: labels[i].Color, Description: labels[i].Description})
+	}
+	return yaml.Marshal(taxonomyYaml)
+}
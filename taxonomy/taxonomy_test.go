@@ -0,0 +1,80 @@
+package taxonomy
+
+import (
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTaxonomy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "taxonomy")
+}
+
+var _ = Describe("taxonomy", func() {
+	labels := []githubstructures.Label{
+		githubstructures.Label{Name: "type: bug", Color: "d73a4a", Description: "Something isn't working"},
+		githubstructures.Label{Name: "blocked", Color: "000000"},
+	}
+
+	It("exports and parses labels", func() {
+		data, err := Export(labels)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(`labels:
+- name: 'type: bug'
+  color: d73a4a
+  description: Something isn't working
+- name: blocked
+  color: "000000"
+`))
+
+		parsedLabels, err := Parse(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsedLabels).To(Equal(labels))
+	})
+
+	It("loads labels from a file", func() {
+		dir, err := ioutil.TempDir("", "taxonomy")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "labels.yml")
+		data, err := Export(labels)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(path, data, 0644)).To(Succeed())
+
+		loadedLabels, err := Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loadedLabels).To(Equal(labels))
+
+		_, err = Load(filepath.Join(dir, "missing.yml"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid labels", func() {
+		_, err := Parse([]byte("labels: [{name: bug, colour: ff0000}]"))
+		Expect(err).To(HaveOccurred())
+
+		_, err = Parse([]byte("labels: [{name: bug}]"))
+		Expect(err).To(MatchError("label #1: both name and color are required"))
+
+		_, err = Parse([]byte("labels: [{name: bug, color: ff0000}, {name: Bug, color: 00ff00}]"))
+		Expect(err).To(MatchError("label #2: duplicated label \"Bug\""))
+
+		_, err = Parse([]byte("labels: [{name: bug, color: ff0000}, {name: wip, color: '#ff00'}]"))
+		Expect(err).To(MatchError("label #2: invalid color \"#ff00\", expected 6 or 3 hex digits like \"d73a4a\" or \"#fff\""))
+	})
+
+	It("normalizes colors", func() {
+		parsedLabels, err := Parse([]byte("labels: [{name: bug, color: '#D73A4A'}, {name: wip, color: FA0}]"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsedLabels).To(Equal([]githubstructures.Label{
+			githubstructures.Label{Name: "bug", Color: "d73a4a"},
+			githubstructures.Label{Name: "wip", Color: "ffaa00"},
+		}))
+	})
+})
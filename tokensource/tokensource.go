@@ -0,0 +1,47 @@
+package tokensource
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func Read(source string) (string, error) {
+	token := ""
+	switch {
+	case strings.HasPrefix(source, "env:"):
+		name := strings.TrimPrefix(source, "env:")
+		token = os.Getenv(name)
+		if token == "" {
+			return "", errors.New("the " + name + " environment variable is empty")
+		}
+	case strings.HasPrefix(source, "file:"):
+		path := strings.TrimPrefix(source, "file:")
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		token = strings.TrimSpace(string(data))
+		if token == "" {
+			return "", errors.New("the " + path + " file is empty")
+		}
+	case strings.HasPrefix(source, "command:"):
+		command := strings.TrimPrefix(source, "command:")
+		output, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			return "", errors.New("the token command failed: " + err.Error())
+		}
+		token = strings.TrimSpace(string(output))
+		if token == "" {
+			return "", errors.New("the token command printed nothing")
+		}
+	default:
+		return "", errors.New("unknown token source \"" + source + "\", expected env:<variable>, file:<path> or command:<shell command>")
+	}
+	if strings.ContainsAny(token, " \t\r\n") {
+		return "", errors.New("the token contains whitespace")
+	}
+	return token, nil
+}
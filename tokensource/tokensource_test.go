@@ -0,0 +1,68 @@
+package tokensource
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokensource(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "tokensource")
+}
+
+var _ = Describe("tokensource", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "tokensource")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		os.Unsetenv("TOKENSOURCE_TEST_TOKEN")
+	})
+
+	It("reads the token from an environment variable", func() {
+		os.Setenv("TOKENSOURCE_TEST_TOKEN", "abc123")
+		Expect(Read("env:TOKENSOURCE_TEST_TOKEN")).To(Equal("abc123"))
+		_, err := Read("env:TOKENSOURCE_MISSING_TOKEN")
+		Expect(err).To(MatchError("the TOKENSOURCE_MISSING_TOKEN environment variable is empty"))
+	})
+
+	It("reads the token from a file", func() {
+		path := filepath.Join(dir, "token")
+		Expect(ioutil.WriteFile(path, []byte("abc123\n"), 0600)).To(Succeed())
+		Expect(Read("file:" + path)).To(Equal("abc123"))
+
+		Expect(ioutil.WriteFile(path, []byte("\n"), 0600)).To(Succeed())
+		_, err := Read("file:" + path)
+		Expect(err).To(MatchError("the " + path + " file is empty"))
+
+		_, err = Read("file:" + filepath.Join(dir, "missing"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads the token from a command", func() {
+		Expect(Read("command:echo abc123")).To(Equal("abc123"))
+		_, err := Read("command:true")
+		Expect(err).To(MatchError("the token command printed nothing"))
+		_, err = Read("command:exit 3")
+		Expect(err).To(MatchError("the token command failed: exit status 3"))
+	})
+
+	It("rejects tokens with whitespace", func() {
+		_, err := Read("command:echo abc 123")
+		Expect(err).To(MatchError("the token contains whitespace"))
+	})
+
+	It("rejects unknown sources", func() {
+		_, err := Read("vault:github")
+		Expect(err).To(MatchError("unknown token source \"vault:github\", expected env:<variable>, file:<path> or command:<shell command>"))
+	})
+})
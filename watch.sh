@@ -1,7 +1,3 @@
 #!/bin/sh
 
-while true
-do
-  time ./issue-overseer "$GITHUB_ORGANIZATION"
-  sleep "$SLEEP_IN_SECONDS"
-done
+exec ./issue-overseer --org "$GITHUB_ORGANIZATION" daemon --interval "${SLEEP_IN_SECONDS}s"
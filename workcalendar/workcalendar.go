@@ -0,0 +1,236 @@
+package workcalendar
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+const maxSearchedDays = 10 * 366
+
+type calendar struct {
+	location          *time.Location
+	workingDays       map[time.Weekday]bool
+	workingHoursStart time.Duration
+	workingHoursEnd   time.Duration
+	holidays          map[string]bool
+}
+
+type systemClock struct {
+}
+
+type HolidayYaml struct {
+	Date string `yaml:"date"`
+	Name string `yaml:"name"`
+}
+
+func New(config githubstructures.CalendarConfig) *calendar {
+	location := config.Location
+	if location == nil {
+		location = time.UTC
+	}
+	workingDays := map[time.Weekday]bool{}
+	for i := 0; i < len(config.WorkingDays); i++ {
+		workingDays[config.WorkingDays[i]] = true
+	}
+	holidays := map[string]bool{}
+	for i := 0; i < len(config.Holidays); i++ {
+		holidays[config.Holidays[i].Format(dateLayout)] = true
+	}
+	return &calendar{location, workingDays, config.WorkingHoursStart, config.WorkingHoursEnd, holidays}
+}
+
+func WallClock() githubstructures.CalendarConfig {
+	return githubstructures.CalendarConfig{
+		Location:          time.UTC,
+		WorkingDays:       []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		WorkingHoursStart: 0,
+		WorkingHoursEnd:   24 * time.Hour,
+		Holidays:          []time.Time{},
+	}
+}
+
+func NewSystemClock() *systemClock {
+	return &systemClock{}
+}
+
+func (clock *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (calendar *calendar) startOfDay(moment time.Time) time.Time {
+	year, month, day := moment.In(calendar.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, calendar.location)
+}
+
+func (calendar *calendar) nextDay(day time.Time) time.Time {
+	year, month, dayOfMonth := day.Date()
+	return time.Date(year, month, dayOfMonth+1, 0, 0, 0, 0, calendar.location)
+}
+
+func (calendar *calendar) at(day time.Time, offset time.Duration) time.Time {
+	year, month, dayOfMonth := day.Date()
+	return time.Date(year, month, dayOfMonth, 0, 0, int(offset/time.Second), 0, calendar.location)
+}
+
+func (calendar *calendar) IsWorkingDay(day time.Time) bool {
+	day = day.In(calendar.location)
+	return calendar.workingDays[day.Weekday()] && !calendar.holidays[day.Format(dateLayout)]
+}
+
+func (calendar *calendar) BusinessDuration(from time.Time, to time.Time) time.Duration {
+	total := time.Duration(0)
+	for day := calendar.startOfDay(from); day.Before(to); day = calendar.nextDay(day) {
+		if !calendar.IsWorkingDay(day) {
+			continue
+		}
+		start := calendar.at(day, calendar.workingHoursStart)
+		if start.Before(from) {
+			start = from
+		}
+		end := calendar.at(day, calendar.workingHoursEnd)
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+func (calendar *calendar) AddBusinessDuration(from time.Time, duration time.Duration) time.Time {
+	remaining := duration
+	day := calendar.startOfDay(from)
+	for i := 0; i < maxSearchedDays; i, day = i+1, calendar.nextDay(day) {
+		if !calendar.IsWorkingDay(day) {
+			continue
+		}
+		start := calendar.at(day, calendar.workingHoursStart)
+		if start.Before(from) {
+			start = from
+		}
+		end := calendar.at(day, calendar.workingHoursEnd)
+		if !end.After(start) {
+			continue
+		}
+		available := end.Sub(start)
+		if remaining <= available {
+			return start.Add(remaining)
+		}
+		remaining -= available
+	}
+	return day
+}
+
+func ParseHolidaysYaml(data []byte) ([]time.Time, error) {
+	entries := []HolidayYaml{}
+	err := yaml.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, err
+	}
+	holidays := []time.Time{}
+	for i := 0; i < len(entries); i++ {
+		holiday, err := time.Parse(dateLayout, entries[i].Date)
+		if err != nil {
+			return nil, errors.New("invalid holiday date \"" + entries[i].Date + "\", expected YYYY-MM-DD")
+		}
+		holidays = append(holidays, holiday)
+	}
+	return holidays, nil
+}
+
+func unfoldICalLines(data []byte) []string {
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func parseICalDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, errors.New("invalid iCal date \"" + value + "\"")
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, errors.New("invalid iCal date \"" + value + "\"")
+	}
+	return date, nil
+}
+
+func ParseHolidaysICal(data []byte) ([]time.Time, error) {
+	holidays := []time.Time{}
+	lines := unfoldICalLines(data)
+	inEvent := false
+	start := ""
+	end := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		separator := strings.Index(line, ":")
+		if separator == -1 {
+			continue
+		}
+		name := strings.ToUpper(strings.SplitN(line[:separator], ";", 2)[0])
+		value := line[separator+1:]
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start = ""
+			end = ""
+		case name == "DTSTART" && inEvent:
+			start = value
+		case name == "DTEND" && inEvent:
+			end = value
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if start == "" {
+				return nil, errors.New("iCal event without DTSTART")
+			}
+			startDate, err := parseICalDate(start)
+			if err != nil {
+				return nil, err
+			}
+			endDate := startDate.AddDate(0, 0, 1)
+			if end != "" {
+				endDate, err = parseICalDate(end)
+				if err != nil {
+					return nil, err
+				}
+			}
+			holidays = append(holidays, startDate)
+			for day := startDate.AddDate(0, 0, 1); day.Before(endDate); day = day.AddDate(0, 0, 1) {
+				holidays = append(holidays, day)
+			}
+		}
+	}
+	return holidays, nil
+}
+
+func LoadHolidays(path string) ([]time.Time, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics", ".ical":
+		return ParseHolidaysICal(data)
+	case ".yml", ".yaml":
+		return ParseHolidaysYaml(data)
+	}
+	return nil, errors.New("unsupported holidays file \"" + path + "\", expected .ics, .yml or .yaml")
+}
@@ -0,0 +1,251 @@
+package workcalendar
+
+import (
+	"github.com/brainhubeu/issue-overseer/githubstructures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkCalendar(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "workcalendar")
+}
+
+var officeHours = githubstructures.CalendarConfig{
+	Location:          time.UTC,
+	WorkingDays:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	WorkingHoursStart: 9 * time.Hour,
+	WorkingHoursEnd:   17 * time.Hour,
+	Holidays:          []time.Time{time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)},
+}
+
+var _ = Describe("workcalendar", func() {
+	_ = Describe("BusinessDuration", func() {
+		It("counts wall-clock time for the wall-clock calendar", func() {
+			calendar := New(WallClock())
+
+			duration := calendar.BusinessDuration(time.Date(2020, 7, 10, 16, 0, 0, 0, time.UTC), time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC))
+
+			Expect(duration).To(Equal(66 * time.Hour))
+		})
+
+		It("uses UTC when there is no location", func() {
+			calendar := New(githubstructures.CalendarConfig{WorkingDays: []time.Weekday{time.Friday}, WorkingHoursEnd: 24 * time.Hour})
+
+			duration := calendar.BusinessDuration(time.Date(2020, 7, 10, 16, 0, 0, 0, time.UTC), time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC))
+
+			Expect(duration).To(Equal(8 * time.Hour))
+		})
+
+		It("skips weekends and hours outside the working hours", func() {
+			calendar := New(officeHours)
+
+			duration := calendar.BusinessDuration(time.Date(2020, 7, 10, 16, 0, 0, 0, time.UTC), time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC))
+
+			Expect(duration).To(Equal(2 * time.Hour))
+		})
+
+		It("skips holidays", func() {
+			calendar := New(officeHours)
+
+			duration := calendar.BusinessDuration(time.Date(2020, 12, 24, 16, 0, 0, 0, time.UTC), time.Date(2020, 12, 28, 10, 0, 0, 0, time.UTC))
+
+			Expect(duration).To(Equal(2 * time.Hour))
+		})
+
+		It("returns zero when the end is before the start", func() {
+			calendar := New(officeHours)
+
+			duration := calendar.BusinessDuration(time.Date(2020, 7, 13, 12, 0, 0, 0, time.UTC), time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC))
+
+			Expect(duration).To(Equal(time.Duration(0)))
+		})
+
+		It("uses the working hours of the calendar time zone", func() {
+			location, err := time.LoadLocation("Europe/Warsaw")
+			Expect(err).NotTo(HaveOccurred())
+			config := officeHours
+			config.Location = location
+			calendar := New(config)
+
+			duration := calendar.BusinessDuration(time.Date(2020, 7, 13, 6, 0, 0, 0, time.UTC), time.Date(2020, 7, 13, 10, 0, 0, 0, time.UTC))
+
+			Expect(duration).To(Equal(3 * time.Hour))
+		})
+	})
+
+	_ = Describe("AddBusinessDuration", func() {
+		It("moves the deadline over a weekend", func() {
+			calendar := New(officeHours)
+
+			deadline := calendar.AddBusinessDuration(time.Date(2020, 7, 10, 15, 0, 0, 0, time.UTC), 4*time.Hour)
+
+			Expect(deadline).To(Equal(time.Date(2020, 7, 13, 11, 0, 0, 0, time.UTC)))
+		})
+
+		It("starts counting at the beginning of the next working hours", func() {
+			calendar := New(officeHours)
+
+			deadline := calendar.AddBusinessDuration(time.Date(2020, 12, 24, 18, 0, 0, 0, time.UTC), time.Hour)
+
+			Expect(deadline).To(Equal(time.Date(2020, 12, 28, 10, 0, 0, 0, time.UTC)))
+		})
+
+		It("is the inverse of BusinessDuration", func() {
+			calendar := New(officeHours)
+			from := time.Date(2020, 7, 9, 11, 30, 0, 0, time.UTC)
+
+			deadline := calendar.AddBusinessDuration(from, 13*time.Hour)
+
+			Expect(calendar.BusinessDuration(from, deadline)).To(Equal(13 * time.Hour))
+		})
+
+		It("gives up after ten years without working hours", func() {
+			from := time.Date(2020, 7, 10, 15, 0, 0, 0, time.UTC)
+			giveUp := time.Date(2030, 7, 18, 0, 0, 0, 0, time.UTC)
+
+			Expect(New(githubstructures.CalendarConfig{}).AddBusinessDuration(from, time.Hour)).To(Equal(giveUp))
+			Expect(New(githubstructures.CalendarConfig{WorkingDays: officeHours.WorkingDays, WorkingHoursStart: 9 * time.Hour, WorkingHoursEnd: 9 * time.Hour}).AddBusinessDuration(from, time.Hour)).To(Equal(giveUp))
+		})
+	})
+
+	_ = Describe("IsWorkingDay", func() {
+		It("returns false for a holiday and a weekend", func() {
+			calendar := New(officeHours)
+
+			Expect(calendar.IsWorkingDay(time.Date(2020, 12, 24, 12, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(calendar.IsWorkingDay(time.Date(2020, 12, 25, 12, 0, 0, 0, time.UTC))).To(BeFalse())
+			Expect(calendar.IsWorkingDay(time.Date(2020, 12, 26, 12, 0, 0, 0, time.UTC))).To(BeFalse())
+		})
+	})
+
+	_ = Describe("ParseHolidaysYaml", func() {
+		It("parses dates", func() {
+			holidays, err := ParseHolidaysYaml([]byte("- date: 2020-12-25\n  name: Christmas\n- date: \"2021-01-01\"\n"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holidays).To(Equal([]time.Time{
+				time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			}))
+		})
+
+		It("rejects an invalid date", func() {
+			_, err := ParseHolidaysYaml([]byte("- date: 25.12.2020\n"))
+
+			Expect(err).To(MatchError("invalid holiday date \"25.12.2020\", expected YYYY-MM-DD"))
+		})
+
+		It("rejects invalid YAML", func() {
+			_, err := ParseHolidaysYaml([]byte("date: 2020-12-25"))
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	_ = Describe("NewSystemClock", func() {
+		It("tells the current time", func() {
+			Expect(NewSystemClock().Now()).To(BeTemporally("~", time.Now(), time.Second))
+		})
+	})
+
+	_ = Describe("ParseHolidaysICal", func() {
+		It("parses one-day and multi-day events", func() {
+			ical := "BEGIN:VCALENDAR\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"DTSTART;VALUE=DATE:20201225\r\n" +
+				"DTEND;VALUE=DATE:20201227\r\n" +
+				"SUMMARY:Christmas\r\n" +
+				"END:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"DTSTART;VALUE=DATE:2021\r\n" +
+				" 0101\r\n" +
+				"SUMMARY:New Year\r\n" +
+				"END:VEVENT\r\n" +
+				"END:VCALENDAR\r\n"
+
+			holidays, err := ParseHolidaysICal([]byte(ical))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holidays).To(Equal([]time.Time{
+				time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 12, 26, 0, 0, 0, 0, time.UTC),
+				time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			}))
+		})
+
+		It("rejects an event without a start", func() {
+			_, err := ParseHolidaysICal([]byte("BEGIN:VEVENT\nSUMMARY:nothing\nEND:VEVENT\n"))
+
+			Expect(err).To(MatchError("iCal event without DTSTART"))
+		})
+
+		It("rejects an invalid date", func() {
+			_, err := ParseHolidaysICal([]byte("BEGIN:VEVENT\nDTSTART:2020\nEND:VEVENT\n"))
+
+			Expect(err).To(MatchError("invalid iCal date \"2020\""))
+
+			_, err = ParseHolidaysICal([]byte("BEGIN:VEVENT\nDTSTART:2020xmas\nEND:VEVENT\n"))
+
+			Expect(err).To(MatchError("invalid iCal date \"2020xmas\""))
+
+			_, err = ParseHolidaysICal([]byte("BEGIN:VEVENT\n\nDTSTART:20201225\nDTEND:2020\nEND:VEVENT\n"))
+
+			Expect(err).To(MatchError("invalid iCal date \"2020\""))
+		})
+	})
+
+	_ = Describe("LoadHolidays", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "workcalendar")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("loads an iCal file", func() {
+			path := filepath.Join(dir, "holidays.ics")
+			Expect(ioutil.WriteFile(path, []byte("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20201225\nEND:VEVENT\n"), 0644)).To(Succeed())
+
+			holidays, err := LoadHolidays(path)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holidays).To(Equal([]time.Time{time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)}))
+		})
+
+		It("loads a YAML file", func() {
+			path := filepath.Join(dir, "holidays.yml")
+			Expect(ioutil.WriteFile(path, []byte("- date: 2020-12-25\n"), 0644)).To(Succeed())
+
+			holidays, err := LoadHolidays(path)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(holidays).To(Equal([]time.Time{time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)}))
+		})
+
+		It("rejects an unknown extension", func() {
+			path := filepath.Join(dir, "holidays.txt")
+			Expect(ioutil.WriteFile(path, []byte(""), 0644)).To(Succeed())
+
+			_, err := LoadHolidays(path)
+
+			Expect(err).To(MatchError("unsupported holidays file \"" + path + "\", expected .ics, .yml or .yaml"))
+		})
+
+		It("fails to load a missing file", func() {
+			_, err := LoadHolidays(filepath.Join(dir, "missing.ics"))
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})